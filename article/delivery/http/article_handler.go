@@ -2,13 +2,18 @@ package http
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/thedevsaddam/govalidator"
 	"go-boilerplate/domain"
+	"go-boilerplate/helper"
 	"go-boilerplate/middleware"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,18 +21,170 @@ type articleHandler struct {
 	articleUsecase domain.ArticleUsecase
 }
 
-func NewArticleHandler(e *echo.Echo, usecase domain.ArticleUsecase)  {
+func NewArticleHandler(e *echo.Echo, usecase domain.ArticleUsecase, customMiddleware *middleware.Middleware) {
 	handler := &articleHandler{articleUsecase: usecase}
 	article := e.Group("/article")
-	customMiddleware := middleware.Init()
 
-	article.GET("/:slug", handler.GetArticleHandler)
-	article.DELETE("/destroy", handler.DestroyArticleHandler, customMiddleware.Auth)
-	article.POST("/store", handler.StoreArticleHandler, customMiddleware.Auth)
-	article.PUT("/update", handler.UpdateArticleHandler, customMiddleware.Auth)
+	article.GET("", handler.FetchArticleHandler, customMiddleware.OptionalAuth)
+	article.GET("/search", handler.SearchArticleHandler)
+	article.GET("/popular", handler.PopularArticleHandler)
+	article.GET("/:slug", handler.GetArticleHandler, customMiddleware.OptionalAuth)
+	article.DELETE("/destroy", handler.DestroyArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.POST("/store", handler.StoreArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.PUT("/update", handler.UpdateArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.POST("/:id/publish", handler.PublishArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.POST("/:id/unpublish", handler.UnpublishArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.POST("/:id/archive", handler.ArchiveArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.GET("/:id/revisions", handler.FetchRevisionHandler, customMiddleware.Auth)
+	article.GET("/:id/revisions/diff", handler.DiffRevisionHandler, customMiddleware.Auth)
+	article.POST("/:id/revisions/:revision/restore", handler.RestoreRevisionHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.POST("/:id/restore", handler.RestoreArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+	article.DELETE("/:id/purge", handler.PurgeArticleHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+}
+
+func (a articleHandler) FetchArticleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"limit":        []string{"numeric_between:1,100"},
+		"offset":       []string{"numeric"},
+		"author":       []string{"uuid"},
+		"created_from": []string{"date"},
+		"created_to":   []string{"date"},
+		"sort":         []string{"in:created_at,updated_at,title"},
+		"order":        []string{"in:asc,desc"},
+		"trashed":      []string{"in:with,only"},
+		"status":       []string{"in:draft,scheduled,published,archived"},
+		"tag":          []string{"alpha_dash"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	filter := domain.ArticleFilter{
+		Cursor:  e.QueryParam("cursor"),
+		Title:   e.QueryParam("title"),
+		Sort:    e.QueryParam("sort"),
+		Order:   e.QueryParam("order"),
+		Trashed: e.QueryParam("trashed"),
+		Status:  e.QueryParam("status"),
+		Tag:     e.QueryParam("tag"),
+	}
+	filter.Limit, _ = strconv.Atoi(e.QueryParam("limit"))
+	filter.Offset, _ = strconv.Atoi(e.QueryParam("offset"))
+	filter.AuthorID, _ = uuid.Parse(e.QueryParam("author"))
+	filter.CreatedFrom = parseDate(e.QueryParam("created_from"))
+	if to := parseDate(e.QueryParam("created_to")); !to.IsZero() {
+		// created_to is inclusive, so include the whole day.
+		filter.CreatedTo = to.AddDate(0, 0, 1)
+	}
+
+	res, err := a.articleUsecase.FetchArticles(ctx, &filter)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) SearchArticleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"q":      []string{"required", "max:255"},
+		"lang":   []string{"alpha_dash"},
+		"limit":  []string{"numeric_between:1,100"},
+		"offset": []string{"numeric"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	search := domain.ArticleSearch{
+		Query:    e.QueryParam("q"),
+		Language: e.QueryParam("lang"),
+	}
+	search.Limit, _ = strconv.Atoi(e.QueryParam("limit"))
+	search.Offset, _ = strconv.Atoi(e.QueryParam("offset"))
+
+	res, err := a.articleUsecase.SearchArticles(ctx, &search)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) PopularArticleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"window": []string{"regex:^[0-9]{1,4}[hd]$"},
+		"limit":  []string{"numeric_between:1,100"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	res, err := a.articleUsecase.PopularArticles(ctx, windowDays(e.QueryParam("window")), limit)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
 }
 
 func (a articleHandler) GetArticleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"format": []string{"in:markdown,html"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
 	ctx := e.Request().Context()
 	if ctx == nil {
 		ctx = context.Background()
@@ -35,7 +192,38 @@ func (a articleHandler) GetArticleHandler(e echo.Context) error {
 	res, err := a.articleUsecase.GetArticleBySlug(ctx,e.Param("slug"))
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return err
+	}
+
+	// The article was found under a slug it had before its title changed.
+	if res.Slug != e.Param("slug") {
+		location := "/article/" + res.Slug
+		if query := e.QueryString(); query != "" {
+			location += "?" + query
+		}
+		return e.Redirect(http.StatusMovedPermanently, location)
+	}
+
+	a.articleUsecase.RecordView(ctx, res, visitorID(e))
+
+	// The view count is part of the body, so it is part of the tag. Each format is a
+	// representation of its own, caches must not answer one with the other.
+	variants := []string{strconv.FormatInt(res.ViewCount, 10)}
+	if format := e.QueryParam("format"); format != "" {
+		variants = append(variants, format)
+	}
+	etag := helper.ETag(res.Version, variants...)
+	e.Response().Header().Set("ETag", etag)
+	if e.Request().Header.Get("If-None-Match") == etag {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	// Both the source and the HTML are returned unless the client picks one.
+	switch e.QueryParam("format") {
+	case "markdown":
+		res.DescriptionHTML = ""
+	case "html":
+		res.Description = ""
 	}
 
 	return e.JSON(http.StatusOK, res)
@@ -45,6 +233,8 @@ func (a articleHandler) StoreArticleHandler(e echo.Context) error {
 	rules := govalidator.MapData{
 		"title":     []string{"required"},
 		"description": []string{"required"},
+		"language":    []string{"alpha_dash"},
+		"cover_id":    []string{"uuid"},
 	}
 
 	validate := govalidator.Options{
@@ -62,6 +252,9 @@ func (a articleHandler) StoreArticleHandler(e echo.Context) error {
 
 	article.Title = e.FormValue("title")
 	article.Description = e.FormValue("description")
+	article.Language = e.FormValue("language")
+	article.Tags = tagsParam(e)
+	article.CoverID, _ = uuid.Parse(e.FormValue("cover_id"))
 	article.CreatedAt = time.Now()
 	article.UpdatedAt = time.Now()
 
@@ -72,7 +265,7 @@ func (a articleHandler) StoreArticleHandler(e echo.Context) error {
 	err := a.articleUsecase.CreateArticle(ctx, &article)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return err
 	}
 
 	return e.JSON(http.StatusOK, map[string]interface{}{
@@ -101,12 +294,17 @@ func (a articleHandler) DestroyArticleHandler(e echo.Context) error {
 	}
 	articleId, err := uuid.Parse(e.FormValue("id"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+	}
+
+	version, err := expectedVersion(e)
+	if err != nil {
+		return versionError(e, err)
 	}
 
-	err = a.articleUsecase.DeleteArticle(ctx, articleId)
+	err = a.articleUsecase.DeleteArticle(ctx, articleId, version)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return versionError(e, err)
 	}
 	return e.JSON(http.StatusOK, map[string]interface{}{
 		"status": "success",
@@ -118,6 +316,8 @@ func (a articleHandler) UpdateArticleHandler(e echo.Context) error {
 	rules := govalidator.MapData{
 		"title":     []string{"required"},
 		"description": []string{"required"},
+		"language":    []string{"alpha_dash"},
+		"cover_id":    []string{"uuid"},
 	}
 
 	validate := govalidator.Options{
@@ -137,19 +337,30 @@ func (a articleHandler) UpdateArticleHandler(e echo.Context) error {
 	articleId, err := uuid.Parse(e.FormValue("id"))
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
 	}
 
 	var article domain.Article
 	article.Title = e.FormValue("title")
 	article.Description = e.FormValue("description")
+	article.Language = e.FormValue("language")
+	article.Tags = tagsParam(e)
+	article.CoverID, _ = uuid.Parse(e.FormValue("cover_id"))
 	article.UpdatedAt = time.Now()
 
+	article.Version, err = expectedVersion(e)
+	if err != nil {
+		return versionError(e, err)
+	}
 
 	res, err := a.articleUsecase.UpdateArticle(ctx, articleId, &article)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return versionError(e, err)
+	}
+
+	if updated, ok := res.(*domain.Article); ok {
+		e.Response().Header().Set("ETag", helper.ETag(updated.Version))
 	}
 
 	return e.JSON(http.StatusOK, map[string]interface{}{
@@ -157,3 +368,294 @@ func (a articleHandler) UpdateArticleHandler(e echo.Context) error {
 		"status": "success",
 	})
 }
+
+func (a articleHandler) PublishArticleHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	// publish_at schedules the article, leaving it out publishes right away.
+	var at time.Time
+	if value := e.FormValue("publish_at"); value != "" {
+		at, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, map[string][]string{
+				"publish_at": {"The publish_at field must be an RFC 3339 date time"},
+			}).SetInternal(errors.New("invalid parameter"))
+		}
+	}
+
+	res, err := a.articleUsecase.PublishArticle(ctx, articleId, at)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) UnpublishArticleHandler(e echo.Context) error {
+	return a.changeStatus(e, a.articleUsecase.UnpublishArticle)
+}
+
+func (a articleHandler) ArchiveArticleHandler(e echo.Context) error {
+	return a.changeStatus(e, a.articleUsecase.ArchiveArticle)
+}
+
+// changeStatus runs a status transition against the article in the :id path param.
+func (a articleHandler) changeStatus(e echo.Context, action func(ctx context.Context, id uuid.UUID) (interface{}, error)) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := action(ctx, articleId)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) FetchRevisionHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := a.articleUsecase.FetchRevisions(ctx, articleId)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) DiffRevisionHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"from": []string{"required", "numeric"},
+		"to":   []string{"numeric"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	// Leaving out to compares against the current content.
+	from, _ := strconv.Atoi(e.QueryParam("from"))
+	to, _ := strconv.Atoi(e.QueryParam("to"))
+
+	res, err := a.articleUsecase.DiffRevisions(ctx, articleId, from, to)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) RestoreRevisionHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	revision, err := strconv.Atoi(e.Param("revision"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	version, err := expectedVersion(e)
+	if err != nil {
+		return versionError(e, err)
+	}
+
+	res, err := a.articleUsecase.RestoreRevision(ctx, articleId, revision, version)
+	if err != nil {
+		return versionError(e, err)
+	}
+
+	if restored, ok := res.(*domain.Article); ok {
+		e.Response().Header().Set("ETag", helper.ETag(restored.Version))
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a articleHandler) RestoreArticleHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	err = a.articleUsecase.RestoreArticle(ctx, articleId)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (a articleHandler) PurgeArticleHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleId, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	err = a.articleUsecase.PurgeArticle(ctx, articleId)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+// tagsParam reads the tags field, repeated or as a comma separated list. It is nil when
+// the field was not sent at all, an empty slice when it was sent empty.
+func tagsParam(e echo.Context) []domain.Tag {
+	params, _ := e.FormParams()
+	values, ok := params["tags"]
+	if !ok {
+		return nil
+	}
+
+	tags := []domain.Tag{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				tags = append(tags, domain.Tag{Name: name})
+			}
+		}
+	}
+	return tags
+}
+
+// expectedVersion reads the article version the client expects from If-Match. It is 0,
+// meaning any version, for "*" or when the header is optional and left out.
+func expectedVersion(e echo.Context) (int, error) {
+	ifMatch := strings.TrimSpace(e.Request().Header.Get("If-Match"))
+	switch {
+	case ifMatch == "" && helper.IfMatchRequired():
+		return 0, domain.ErrPreconditionRequired
+	case ifMatch == "" || ifMatch == "*":
+		return 0, nil
+	}
+
+	version, err := helper.ParseETag(ifMatch)
+	if err != nil {
+		// A tag we never issued can not match the current one.
+		return 0, domain.ErrVersionConflict
+	}
+	return version, nil
+}
+
+// windowDays converts a window like "7d" or "48h" to whole days, views are only
+// kept per day. Zero leaves the choice to the usecase.
+func windowDays(window string) int {
+	if window == "" {
+		return 0
+	}
+	n, _ := strconv.Atoi(window[:len(window)-1])
+	if strings.HasSuffix(window, "h") {
+		return (n + 23) / 24
+	}
+	return n
+}
+
+// visitorID identifies who reads an article for deduplicating views. Anonymous
+// readers are told apart by address and user agent, hashed so neither is kept.
+func visitorID(e echo.Context) string {
+	if principal, ok := helper.PrincipalFromContext(e.Request().Context()); ok {
+		return "user:" + principal.UserID.String()
+	}
+	// Forwarding headers are only believed from the trusted proxies, anyone can send them.
+	extractIP := e.Echo().IPExtractor
+	if extractIP == nil {
+		extractIP = echo.ExtractIPDirect()
+	}
+	sum := sha256.Sum256([]byte(extractIP(e.Request()) + "\x00" + e.Request().UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:])
+}
+
+// versionError is the error response for a failed write, version conflicts carry
+// the version the article is at now.
+func versionError(e echo.Context, err error) error {
+	var conflict *domain.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	e.Response().Header().Set("ETag", helper.ETag(conflict.Current))
+	return echo.NewHTTPError(http.StatusPreconditionFailed, map[string]interface{}{
+		"currentVersion": conflict.Current,
+	}).SetInternal(err)
+}
+
+// parseDate reads a yyyy-mm-dd or yyyy/mm/dd query value, returning the zero time when it is empty.
+func parseDate(value string) time.Time {
+	date, _ := time.Parse("2006-01-02", strings.ReplaceAll(value, "/", "-"))
+	return date
+}
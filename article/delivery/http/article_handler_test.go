@@ -0,0 +1,552 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	_articleHttpDelivery "go-boilerplate/article/delivery/http"
+	"go-boilerplate/article/repository/memory"
+	"go-boilerplate/article/usecase"
+	"go-boilerplate/article/views"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/middleware"
+	_userMemoryRepository "go-boilerplate/user/repository/memory"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("JWT_SECRET", "test-secret")
+	viper.Set("JWT_EXPIRED_TOKEN_DURATION", 15)
+	logrus.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// tagRepository hands out ids for new tags, it only implements what the article usecase uses.
+type tagRepository struct {
+	domain.TagRepository
+}
+
+func (tagRepository) Ensure(ctx context.Context, tags []domain.Tag) ([]domain.Tag, error) {
+	for i := range tags {
+		tags[i].ID = uuid.New()
+	}
+	return tags, nil
+}
+
+func (tagRepository) SetArticleTags(ctx context.Context, articleID uuid.UUID, tagIDs []uuid.UUID) error {
+	return nil
+}
+
+func (tagRepository) FetchByArticles(ctx context.Context, articleIDs []uuid.UUID) (map[uuid.UUID][]domain.Tag, error) {
+	return map[uuid.UUID][]domain.Tag{}, nil
+}
+
+// mediaUsecase knows no media, it only implements what the article usecase uses.
+type mediaUsecase struct {
+	domain.MediaUsecase
+}
+
+func (mediaUsecase) Find(ctx context.Context, id uuid.UUID) (*domain.Media, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (mediaUsecase) FindMany(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Media, error) {
+	return map[uuid.UUID]*domain.Media{}, nil
+}
+
+type scheduler struct{}
+
+func (scheduler) Wake() {}
+
+type server struct {
+	echo     *echo.Echo
+	articles domain.ArticleRepository
+	users    domain.UserRepository
+	views    *views.Counter
+}
+
+func newServer() *server {
+	s := &server{
+		echo:     echo.New(),
+		articles: memory.NewMemoryArticleRepository(),
+		users:    _userMemoryRepository.NewMemoryUserRepository(),
+	}
+	s.views = views.NewCounter(s.articles, time.Hour, time.Hour)
+	customMiddleware := middleware.Init(s.users, _userMemoryRepository.NewMemoryRefreshTokenRepository())
+	s.echo.HTTPErrorHandler = customMiddleware.ErrorHandler
+
+	articleUsecase := usecase.NewArticleUsecase(s.articles, tagRepository{}, mediaUsecase{}, scheduler{}, s.views, _userMemoryRepository.NewMemoryTransactor(), time.Second)
+	_articleHttpDelivery.NewArticleHandler(s.echo, articleUsecase, customMiddleware)
+	return s
+}
+
+// token signs an access token for a stored user holding permissions.
+func (s *server) token(t *testing.T, email string, permissions ...string) (uuid.UUID, string) {
+	t.Helper()
+	usr := &domain.User{ID: uuid.New(), Name: "Jane", Email: email, Password: "secret", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := s.users.CreateUser(context.Background(), usr); err != nil {
+		t.Fatal(err)
+	}
+
+	usr.Roles, usr.Permissions = []string{}, permissions
+	token, _, _, err := helper.GenerateJwt(context.Background(), usr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return usr.ID, token
+}
+
+type request struct {
+	name        string
+	method      string
+	path        string
+	form        url.Values
+	token       string
+	ifMatch     string
+	ifNoneMatch string
+	header      map[string]string
+	want        int
+	wantHeader  map[string]string
+	// wantBody maps dotted paths into the JSON response, e.g. error.code, to the values expected there.
+	wantBody map[string]string
+}
+
+// do sends form as the request body, or as the query string for GET and DELETE requests
+// since their bodies are not parsed.
+func (s *server) do(tt request) *httptest.ResponseRecorder {
+	path, body := tt.path, ""
+	if tt.method == http.MethodGet || tt.method == http.MethodDelete {
+		if len(tt.form) > 0 {
+			path += "?" + tt.form.Encode()
+		}
+	} else {
+		body = tt.form.Encode()
+	}
+
+	req := httptest.NewRequest(tt.method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	if tt.token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+	}
+	if tt.ifMatch != "" {
+		req.Header.Set("If-Match", tt.ifMatch)
+	}
+	if tt.ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", tt.ifNoneMatch)
+	}
+	for key, value := range tt.header {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func run(t *testing.T, s *server, tests []request) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+			}
+			for key, value := range tt.wantHeader {
+				if got := rec.Header().Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+			checkBody(t, rec, tt.wantBody)
+		})
+	}
+}
+
+func checkBody(t *testing.T, rec *httptest.ResponseRecorder, want map[string]string) {
+	t.Helper()
+	if len(want) == 0 {
+		return
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	for path, value := range want {
+		if got := field(body, path); got != value {
+			t.Errorf("%s = %s, want %s", path, got, value)
+		}
+	}
+}
+
+// field formats the value at a dotted path of a decoded JSON document, list elements
+// are picked by their index.
+func field(value interface{}, path string) string {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "<missing>"
+			}
+			value = v[i]
+		default:
+			return "<missing>"
+		}
+	}
+	if value == nil {
+		return "<missing>"
+	}
+	return fmt.Sprint(value)
+}
+
+// store creates an article through the API and returns it as stored.
+func (s *server) store(t *testing.T, token, title string) *domain.Article {
+	t.Helper()
+	rec := s.do(request{method: http.MethodPost, path: "/article/store", token: token,
+		form: url.Values{"title": {title}, "description": {"About " + title}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("store %q: %d %s", title, rec.Code, rec.Body)
+	}
+
+	art, err := s.articles.FindBy(context.Background(), "title", title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return art
+}
+
+func (s *server) publish(t *testing.T, art *domain.Article) {
+	t.Helper()
+	if err := s.articles.SetStatus(context.Background(), art.ID, domain.ArticleStatusPublished, pg.NullTime{Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreArticleHandler(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+
+	run(t, s, []request{
+		{name: "store", method: http.MethodPost, path: "/article/store", token: author,
+			form: url.Values{"title": {"Hello"}, "description": {"World"}, "tags": {"go,web"}}, want: http.StatusOK,
+			wantBody: map[string]string{"status": "success"}},
+		{name: "missing description", method: http.MethodPost, path: "/article/store", token: author,
+			form: url.Values{"title": {"Hello"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "validation_failed"}},
+		{name: "malformed cover", method: http.MethodPost, path: "/article/store", token: author,
+			form: url.Values{"title": {"Hello"}, "description": {"World"}, "cover_id": {"nope"}}, want: http.StatusUnprocessableEntity},
+		{name: "unknown cover", method: http.MethodPost, path: "/article/store", token: author,
+			form: url.Values{"title": {"Hello"}, "description": {"World"}, "cover_id": {uuid.New().String()}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_cover"}},
+		{name: "anonymous", method: http.MethodPost, path: "/article/store",
+			form: url.Values{"title": {"Hello"}, "description": {"World"}}, want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "unauthorized"}},
+	})
+}
+
+func TestStoreArticleHandlerRequiresVerifiedEmail(t *testing.T) {
+	viper.Set("EMAIL_VERIFICATION_REQUIRED", true)
+	defer viper.Set("EMAIL_VERIFICATION_REQUIRED", false)
+
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+
+	run(t, s, []request{
+		{name: "unverified", method: http.MethodPost, path: "/article/store", token: author,
+			form: url.Values{"title": {"Hello"}, "description": {"World"}}, want: http.StatusForbidden,
+			wantBody: map[string]string{"error.code": "email_not_verified"}},
+	})
+}
+
+func TestFetchArticleHandler(t *testing.T) {
+	s := newServer()
+	authorID, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	_, editor := s.token(t, "editor@example.com", domain.PermissionArticlesDelete)
+	s.publish(t, s.store(t, author, "Published"))
+	s.store(t, author, "Draft")
+
+	run(t, s, []request{
+		{name: "published", method: http.MethodGet, path: "/article", want: http.StatusOK,
+			wantBody: map[string]string{"data.articles.0.name": "Published", "data.meta.total": "1"}},
+		{name: "sorted by title", method: http.MethodGet, path: "/article",
+			form: url.Values{"sort": {"title"}, "order": {"asc"}, "limit": {"10"}}, want: http.StatusOK},
+		{name: "own drafts", method: http.MethodGet, path: "/article", token: author,
+			form: url.Values{"status": {"draft"}, "author": {authorID.String()}}, want: http.StatusOK,
+			wantBody: map[string]string{"data.articles.0.name": "Draft", "data.meta.total": "1"}},
+		{name: "someone else's drafts", method: http.MethodGet, path: "/article", token: other,
+			form: url.Values{"status": {"draft"}, "author": {authorID.String()}}, want: http.StatusForbidden,
+			wantBody: map[string]string{"error.code": "forbidden"}},
+		{name: "drafts anonymously", method: http.MethodGet, path: "/article",
+			form: url.Values{"status": {"draft"}}, want: http.StatusUnauthorized},
+		{name: "trash with permission", method: http.MethodGet, path: "/article", token: editor,
+			form: url.Values{"trashed": {"only"}}, want: http.StatusOK},
+		{name: "trash without permission", method: http.MethodGet, path: "/article", token: author,
+			form: url.Values{"trashed": {"only"}}, want: http.StatusForbidden},
+		{name: "unknown sort", method: http.MethodGet, path: "/article",
+			form: url.Values{"sort": {"views"}}, want: http.StatusUnprocessableEntity},
+		{name: "limit out of range", method: http.MethodGet, path: "/article",
+			form: url.Values{"limit": {"1000"}}, want: http.StatusUnprocessableEntity},
+		{name: "malformed cursor", method: http.MethodGet, path: "/article",
+			form: url.Values{"cursor": {"%%%"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_cursor"}},
+		{name: "bad token", method: http.MethodGet, path: "/article", token: "garbage", want: http.StatusUnauthorized},
+	})
+}
+
+func TestSearchArticleHandler(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	s.publish(t, s.store(t, author, "Go concurrency"))
+
+	run(t, s, []request{
+		{name: "search", method: http.MethodGet, path: "/article/search",
+			form: url.Values{"q": {"concurrency"}}, want: http.StatusOK,
+			wantBody: map[string]string{"data.articles.0.name": "Go concurrency", "data.meta.total": "1"}},
+		{name: "missing query", method: http.MethodGet, path: "/article/search", want: http.StatusUnprocessableEntity},
+		{name: "unknown language", method: http.MethodGet, path: "/article/search",
+			form: url.Values{"q": {"go"}, "lang": {"klingon"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "unknown_language"}},
+	})
+}
+
+func TestPopularArticleHandler(t *testing.T) {
+	s := newServer()
+
+	run(t, s, []request{
+		{name: "default window", method: http.MethodGet, path: "/article/popular", want: http.StatusOK},
+		{name: "hours", method: http.MethodGet, path: "/article/popular",
+			form: url.Values{"window": {"36h"}, "limit": {"5"}}, want: http.StatusOK},
+		{name: "malformed window", method: http.MethodGet, path: "/article/popular",
+			form: url.Values{"window": {"week"}}, want: http.StatusUnprocessableEntity},
+		{name: "limit out of range", method: http.MethodGet, path: "/article/popular",
+			form: url.Values{"limit": {"0"}}, want: http.StatusUnprocessableEntity},
+	})
+}
+
+func TestGetArticleHandler(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	art := s.store(t, author, "Before")
+	s.publish(t, art)
+	s.store(t, author, "Draft")
+
+	rec := s.do(request{method: http.MethodPut, path: "/article/update", token: author, ifMatch: "*",
+		form: url.Values{"id": {art.ID.String()}, "title": {"After"}, "description": {"x"}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rename: %d %s", rec.Code, rec.Body)
+	}
+	version, err := helper.ParseETag(rec.Header().Get("ETag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := helper.ETag(version, "0")
+
+	run(t, s, []request{
+		{name: "published", method: http.MethodGet, path: "/article/after", want: http.StatusOK,
+			wantBody:   map[string]string{"name": "After", "slug": "after", "descriptionHtml": "<p>x</p>\n", "description": "x"},
+			wantHeader: map[string]string{"ETag": etag}},
+		{name: "markdown only", method: http.MethodGet, path: "/article/after",
+			form: url.Values{"format": {"markdown"}}, want: http.StatusOK,
+			wantBody:   map[string]string{"description": "x", "descriptionHtml": "<missing>"},
+			wantHeader: map[string]string{"ETag": helper.ETag(version, "0", "markdown")}},
+		{name: "modified in another format", method: http.MethodGet, path: "/article/after",
+			form: url.Values{"format": {"html"}}, ifNoneMatch: helper.ETag(version, "0", "markdown"), want: http.StatusOK},
+		{name: "not modified in a format", method: http.MethodGet, path: "/article/after",
+			form: url.Values{"format": {"html"}}, ifNoneMatch: helper.ETag(version, "0", "html"), want: http.StatusNotModified},
+		{name: "unknown format", method: http.MethodGet, path: "/article/after",
+			form: url.Values{"format": {"pdf"}}, want: http.StatusUnprocessableEntity},
+		{name: "not modified", method: http.MethodGet, path: "/article/after", ifNoneMatch: etag, want: http.StatusNotModified},
+		{name: "old slug", method: http.MethodGet, path: "/article/before", want: http.StatusMovedPermanently,
+			wantHeader: map[string]string{"Location": "/article/after"}},
+		{name: "draft anonymously", method: http.MethodGet, path: "/article/draft", want: http.StatusNotFound},
+		{name: "draft by its author", method: http.MethodGet, path: "/article/draft", token: author, want: http.StatusOK},
+		{name: "unknown slug", method: http.MethodGet, path: "/article/nothing", want: http.StatusNotFound,
+			wantBody: map[string]string{"error.code": "not_found"}},
+		{name: "forwarded for someone else", method: http.MethodGet, path: "/article/after",
+			header: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, want: http.StatusOK},
+	})
+
+	s.views.Flush(context.Background())
+	popular, err := s.articles.Popular(context.Background(), time.Now().UTC(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(popular) != 1 || popular[0].RecentViews != 1 {
+		t.Errorf("popular = %+v, want one article read once", popular)
+	}
+
+	run(t, s, []request{
+		{name: "modified by its views", method: http.MethodGet, path: "/article/after", ifNoneMatch: etag, want: http.StatusOK,
+			wantHeader: map[string]string{"ETag": helper.ETag(version, "1")}},
+	})
+}
+
+func TestUpdateArticleHandler(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	art := s.store(t, author, "Original")
+	id := art.ID.String()
+	form := url.Values{"id": {id}, "title": {"Edited"}, "description": {"x"}}
+
+	run(t, s, []request{
+		{name: "without If-Match", method: http.MethodPut, path: "/article/update", token: author,
+			form: form, want: http.StatusPreconditionRequired,
+			wantBody: map[string]string{"error.code": "precondition_required"}},
+		{name: "stale If-Match", method: http.MethodPut, path: "/article/update", token: author,
+			form: form, ifMatch: helper.ETag(7), want: http.StatusPreconditionFailed,
+			wantBody:   map[string]string{"error.code": "version_conflict"},
+			wantHeader: map[string]string{"ETag": helper.ETag(1)}},
+		{name: "someone else", method: http.MethodPut, path: "/article/update", token: other,
+			form: form, ifMatch: helper.ETag(1), want: http.StatusForbidden,
+			wantBody: map[string]string{"error.code": "forbidden"}},
+		{name: "missing title", method: http.MethodPut, path: "/article/update", token: author,
+			form: url.Values{"id": {id}, "description": {"x"}}, ifMatch: helper.ETag(1), want: http.StatusUnprocessableEntity},
+		{name: "update", method: http.MethodPut, path: "/article/update", token: author,
+			form: form, ifMatch: helper.ETag(1), want: http.StatusOK,
+			wantBody:   map[string]string{"data.name": "Edited", "data.version": "2"},
+			wantHeader: map[string]string{"ETag": helper.ETag(2)}},
+		{name: "unknown article", method: http.MethodPut, path: "/article/update", token: author,
+			form: url.Values{"id": {uuid.New().String()}, "title": {"x"}, "description": {"x"}}, ifMatch: "*", want: http.StatusNotFound,
+			wantBody: map[string]string{"error.code": "not_found"}},
+		{name: "anonymous", method: http.MethodPut, path: "/article/update",
+			form: form, ifMatch: "*", want: http.StatusUnauthorized},
+	})
+}
+
+func TestDestroyArticleHandler(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	id := s.store(t, author, "Doomed").ID.String()
+
+	run(t, s, []request{
+		{name: "without If-Match", method: http.MethodDelete, path: "/article/destroy", token: author,
+			form: url.Values{"id": {id}}, want: http.StatusPreconditionRequired},
+		{name: "stale If-Match", method: http.MethodDelete, path: "/article/destroy", token: author,
+			form: url.Values{"id": {id}}, ifMatch: helper.ETag(3), want: http.StatusPreconditionFailed,
+			wantBody: map[string]string{"error.code": "version_conflict"}},
+		{name: "someone else", method: http.MethodDelete, path: "/article/destroy", token: other,
+			form: url.Values{"id": {id}}, ifMatch: "*", want: http.StatusForbidden},
+		{name: "missing id", method: http.MethodDelete, path: "/article/destroy", token: author,
+			ifMatch: "*", want: http.StatusUnprocessableEntity},
+		{name: "destroy", method: http.MethodDelete, path: "/article/destroy", token: author,
+			form: url.Values{"id": {id}}, ifMatch: helper.ETag(1), want: http.StatusOK},
+		{name: "anonymous", method: http.MethodDelete, path: "/article/destroy",
+			form: url.Values{"id": {id}}, ifMatch: "*", want: http.StatusUnauthorized},
+	})
+}
+
+func TestStatusHandlers(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	path := "/article/" + s.store(t, author, "Status").ID.String()
+
+	run(t, s, []request{
+		{name: "publish", method: http.MethodPost, path: path + "/publish", token: author, want: http.StatusOK,
+			wantBody: map[string]string{"data.status": "published"}},
+		{name: "schedule", method: http.MethodPost, path: path + "/publish", token: author,
+			form: url.Values{"publish_at": {time.Now().Add(time.Hour).Format(time.RFC3339)}}, want: http.StatusOK,
+			wantBody: map[string]string{"data.status": "scheduled"}},
+		{name: "malformed publish_at", method: http.MethodPost, path: path + "/publish", token: author,
+			form: url.Values{"publish_at": {"tomorrow"}}, want: http.StatusUnprocessableEntity},
+		{name: "publish someone else's", method: http.MethodPost, path: path + "/publish", token: other, want: http.StatusForbidden},
+		{name: "unpublish", method: http.MethodPost, path: path + "/unpublish", token: author, want: http.StatusOK},
+		{name: "archive", method: http.MethodPost, path: path + "/archive", token: author, want: http.StatusOK,
+			wantBody: map[string]string{"data.status": "archived"}},
+		{name: "archive anonymously", method: http.MethodPost, path: path + "/archive", want: http.StatusUnauthorized},
+		{name: "unknown article", method: http.MethodPost, path: "/article/" + uuid.New().String() + "/publish", token: author, want: http.StatusNotFound},
+		{name: "malformed id", method: http.MethodPost, path: "/article/nope/unpublish", token: author, want: http.StatusNotFound},
+	})
+}
+
+func TestRevisionHandlers(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	art := s.store(t, author, "First")
+	path := "/article/" + art.ID.String()
+
+	rec := s.do(request{method: http.MethodPut, path: "/article/update", token: author, ifMatch: "*",
+		form: url.Values{"id": {art.ID.String()}, "title": {"Second"}, "description": {"x"}}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+
+	run(t, s, []request{
+		{name: "list", method: http.MethodGet, path: path + "/revisions", token: author, want: http.StatusOK,
+			wantBody: map[string]string{"data.0.revision": "1", "data.0.title": "First"}},
+		{name: "list someone else's", method: http.MethodGet, path: path + "/revisions", token: other, want: http.StatusForbidden},
+		{name: "list anonymously", method: http.MethodGet, path: path + "/revisions", want: http.StatusUnauthorized},
+		{name: "diff", method: http.MethodGet, path: path + "/revisions/diff", token: author,
+			form: url.Values{"from": {"1"}}, want: http.StatusOK},
+		{name: "diff without from", method: http.MethodGet, path: path + "/revisions/diff", token: author, want: http.StatusUnprocessableEntity},
+		{name: "diff an unknown revision", method: http.MethodGet, path: path + "/revisions/diff", token: author,
+			form: url.Values{"from": {"9"}}, want: http.StatusNotFound,
+			wantBody: map[string]string{"error.code": "not_found"}},
+		{name: "restore without If-Match", method: http.MethodPost, path: path + "/revisions/1/restore", token: author,
+			want: http.StatusPreconditionRequired, wantBody: map[string]string{"error.code": "precondition_required"}},
+		{name: "restore with a stale If-Match", method: http.MethodPost, path: path + "/revisions/1/restore", token: author,
+			ifMatch: helper.ETag(1), want: http.StatusPreconditionFailed,
+			wantHeader: map[string]string{"ETag": helper.ETag(2)},
+			wantBody:   map[string]string{"error.code": "version_conflict"}},
+		{name: "restore", method: http.MethodPost, path: path + "/revisions/1/restore", token: author,
+			ifMatch: helper.ETag(2), want: http.StatusOK,
+			wantHeader: map[string]string{"ETag": helper.ETag(3)},
+			wantBody:   map[string]string{"data.name": "First"}},
+		{name: "restore someone else's", method: http.MethodPost, path: path + "/revisions/1/restore", token: other,
+			ifMatch: "*", want: http.StatusForbidden},
+		{name: "restore a malformed revision", method: http.MethodPost, path: path + "/revisions/first/restore", token: author, want: http.StatusNotFound},
+	})
+}
+
+func TestTrashHandlers(t *testing.T) {
+	s := newServer()
+	_, author := s.token(t, "author@example.com")
+	_, other := s.token(t, "other@example.com")
+	art := s.store(t, author, "Trash")
+	path := "/article/" + art.ID.String()
+
+	run(t, s, []request{
+		{name: "restore a live article", method: http.MethodPost, path: path + "/restore", token: author, want: http.StatusNotFound},
+		{name: "purge a live article", method: http.MethodDelete, path: path + "/purge", token: author, want: http.StatusNotFound},
+	})
+
+	if err := s.articles.Delete(context.Background(), art.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, s, []request{
+		{name: "restore someone else's", method: http.MethodPost, path: path + "/restore", token: other, want: http.StatusForbidden},
+		{name: "restore", method: http.MethodPost, path: path + "/restore", token: author, want: http.StatusOK,
+			wantBody: map[string]string{"data.deletedAt": "<missing>"}},
+	})
+
+	if err := s.articles.Delete(context.Background(), art.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, s, []request{
+		{name: "purge someone else's", method: http.MethodDelete, path: path + "/purge", token: other, want: http.StatusForbidden},
+		{name: "purge anonymously", method: http.MethodDelete, path: path + "/purge", want: http.StatusUnauthorized},
+		{name: "purge", method: http.MethodDelete, path: path + "/purge", token: author, want: http.StatusOK},
+		{name: "purge twice", method: http.MethodDelete, path: path + "/purge", token: author, want: http.StatusNotFound},
+	})
+}
@@ -0,0 +1,580 @@
+// Package contract checks that a domain.ArticleRepository behaves the way the usecases
+// rely on, whatever it is backed by. Implementations call ArticleRepository from their tests.
+package contract
+
+import (
+	"context"
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Repositories is what the contract runs against. Users is there for the authors
+// articles refer to.
+type Repositories struct {
+	Articles domain.ArticleRepository
+	Users    domain.UserRepository
+}
+
+// ArticleRepository runs the contract, every subtest gets fresh repositories from newRepositories.
+func ArticleRepository(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, f *fixture)
+	}{
+		{"Create stores the article with column defaults", create},
+		{"Create rejects a taken slug", createTakenSlug},
+		{"Find methods miss unknown articles", findMissing},
+		{"Update records a revision and the old slug", update},
+		{"Update hands a slug back to its article", updateReclaimSlug},
+		{"Update rejects a stale version", updateConflict},
+		{"Update rejects a taken slug", updateTakenSlug},
+		{"Update misses unknown articles", updateUnknown},
+		{"Delete, Restore and ForceDelete follow the trash", trash},
+		{"Fetch filters, sorts and pages", fetch},
+		{"Fetch continues from a cursor", fetchCursor},
+		{"TakenSlugs lists numbered and old slugs", takenSlugs},
+		{"SetStatus bumps the version", setStatus},
+		{"PublishDue and NextScheduled follow the schedule", schedule},
+		{"Anonymize clears the author", anonymize},
+		{"AddViews and Popular rank recent reads", views},
+		{"Search finds published articles", search},
+		{"Search rejects unknown languages", searchUnknownLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newRepositories(t)
+			tt.run(t, &fixture{Repositories: repos, author: author(t, repos.Users)})
+		})
+	}
+}
+
+type fixture struct {
+	Repositories
+	author uuid.UUID
+}
+
+func author(t *testing.T, users domain.UserRepository) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	usr := &domain.User{ID: id, Name: "Jane", Email: id.String() + "@example.com", Password: "x"}
+	if err := users.CreateUser(context.Background(), usr); err != nil {
+		t.Fatalf("create author: %v", err)
+	}
+	return id
+}
+
+// create stores an article by the fixture's author, ages are whole seconds since
+// PostgreSQL keeps no more.
+func (f *fixture) create(t *testing.T, title string, age time.Duration) *domain.Article {
+	t.Helper()
+	at := time.Now().UTC().Truncate(time.Second).Add(-age)
+	art := &domain.Article{
+		ID:          uuid.New(),
+		Title:       title,
+		Slug:        strings.ToLower(strings.ReplaceAll(title, " ", "-")),
+		Description: "About " + title,
+		AuthorID:    f.author,
+		CreatedAt:   at,
+		UpdatedAt:   at,
+	}
+	if err := f.Articles.Create(context.Background(), art); err != nil {
+		t.Fatalf("create %q: %v", title, err)
+	}
+	return art
+}
+
+func (f *fixture) publish(t *testing.T, art *domain.Article) {
+	t.Helper()
+	if err := f.Articles.SetStatus(context.Background(), art.ID, domain.ArticleStatusPublished, pg.NullTime{Time: art.CreatedAt}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) find(t *testing.T, id uuid.UUID) *domain.Article {
+	t.Helper()
+	art, err := f.Articles.FindWithTrashed(context.Background(), id)
+	if err != nil {
+		t.Fatalf("find %v: %v", id, err)
+	}
+	return art
+}
+
+func wantErr(t *testing.T, action string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: err = %v, want %v", action, err, want)
+	}
+}
+
+func wantConflict(t *testing.T, action string, err error, current int) {
+	t.Helper()
+	var conflict *domain.VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Current != current {
+		t.Fatalf("%s: err = %v, want a conflict with version %d", action, err, current)
+	}
+}
+
+func titles(articles []domain.Article) []string {
+	res := make([]string, len(articles))
+	for i, art := range articles {
+		res[i] = art.Title
+	}
+	return res
+}
+
+func create(t *testing.T, f *fixture) {
+	art := f.create(t, "Hello World", 0)
+
+	stored, err := f.Articles.FindBy(context.Background(), "slug", "hello-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID != art.ID || stored.Title != art.Title || stored.AuthorID != f.author {
+		t.Errorf("stored %+v, want %+v", stored, art)
+	}
+	if stored.Status != domain.ArticleStatusDraft || stored.Version != 1 || stored.Language != "english" {
+		t.Errorf("stored %s at version %d in %q, want the column defaults", stored.Status, stored.Version, stored.Language)
+	}
+}
+
+func createTakenSlug(t *testing.T, f *fixture) {
+	f.create(t, "Hello", 0)
+
+	err := f.Articles.Create(context.Background(), &domain.Article{ID: uuid.New(), Title: "Hello", Slug: "hello", Description: "x"})
+	wantErr(t, "Create", err, domain.ErrSlugTaken)
+}
+
+func findMissing(t *testing.T, f *fixture) {
+	ctx := context.Background()
+
+	_, err := f.Articles.FindBy(ctx, "id", uuid.New().String())
+	wantErr(t, "FindBy id", err, domain.ErrNotFound)
+	_, err = f.Articles.FindBy(ctx, "slug", "nothing")
+	wantErr(t, "FindBy slug", err, domain.ErrNotFound)
+	_, err = f.Articles.FindByOldSlug(ctx, "nothing")
+	wantErr(t, "FindByOldSlug", err, domain.ErrNotFound)
+	_, err = f.Articles.FindWithTrashed(ctx, uuid.New())
+	wantErr(t, "FindWithTrashed", err, domain.ErrNotFound)
+	_, err = f.Articles.FindRevision(ctx, uuid.New(), 1)
+	wantErr(t, "FindRevision", err, domain.ErrNotFound)
+}
+
+func update(t *testing.T, f *fixture) {
+	art := f.create(t, "Before", 0)
+	ctx := context.Background()
+
+	res, err := f.Articles.Update(ctx, art.ID, &domain.Article{Title: "After", Slug: "after", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != 2 {
+		t.Errorf("returned version %d, want 2", res.Version)
+	}
+
+	stored := f.find(t, art.ID)
+	if stored.Title != "After" || stored.Slug != "after" || stored.Description != art.Description || stored.Version != 2 {
+		t.Errorf("stored %q at %q with %q, version %d", stored.Title, stored.Slug, stored.Description, stored.Version)
+	}
+
+	moved, err := f.Articles.FindByOldSlug(ctx, "before")
+	if err != nil || moved.ID != art.ID {
+		t.Errorf("FindByOldSlug: got %v, %v", moved, err)
+	}
+
+	revisions, err := f.Articles.FetchRevisions(ctx, art.ID)
+	if err != nil || len(revisions) != 1 || revisions[0].Revision != 1 || revisions[0].Title != "Before" {
+		t.Fatalf("FetchRevisions: got %+v, %v", revisions, err)
+	}
+	rev, err := f.Articles.FindRevision(ctx, art.ID, 1)
+	if err != nil || rev.Description != art.Description {
+		t.Errorf("FindRevision: got %+v, %v", rev, err)
+	}
+	_, err = f.Articles.FindRevision(ctx, art.ID, 2)
+	wantErr(t, "FindRevision of the current content", err, domain.ErrNotFound)
+
+	if _, err := f.Articles.Update(ctx, art.ID, &domain.Article{Description: "Second edit"}); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err = f.Articles.FetchRevisions(ctx, art.ID)
+	if err != nil || len(revisions) != 2 || revisions[0].Revision != 2 || revisions[0].Title != "After" {
+		t.Errorf("FetchRevisions after a second edit: got %+v, %v", revisions, err)
+	}
+}
+
+func updateReclaimSlug(t *testing.T, f *fixture) {
+	art := f.create(t, "First", 0)
+	ctx := context.Background()
+
+	for _, slug := range []string{"second", "first"} {
+		if _, err := f.Articles.Update(ctx, art.ID, &domain.Article{Title: slug, Slug: slug}); err != nil {
+			t.Fatalf("rename to %s: %v", slug, err)
+		}
+	}
+
+	if stored, err := f.Articles.FindBy(ctx, "slug", "first"); err != nil || stored.ID != art.ID {
+		t.Errorf("FindBy reclaimed slug: got %v, %v", stored, err)
+	}
+	_, err := f.Articles.FindByOldSlug(ctx, "first")
+	wantErr(t, "FindByOldSlug reclaimed slug", err, domain.ErrNotFound)
+	if moved, err := f.Articles.FindByOldSlug(ctx, "second"); err != nil || moved.ID != art.ID {
+		t.Errorf("FindByOldSlug: got %v, %v", moved, err)
+	}
+}
+
+func updateConflict(t *testing.T, f *fixture) {
+	art := f.create(t, "Original", 0)
+
+	edit := &domain.Article{Title: "Edited", Version: 5}
+	_, err := f.Articles.Update(context.Background(), art.ID, edit)
+	wantConflict(t, "Update", err, 1)
+	if edit.Version != 5 {
+		t.Errorf("failed update left version %d on the article passed in, want 5", edit.Version)
+	}
+
+	if stored := f.find(t, art.ID); stored.Title != "Original" || stored.Version != 1 {
+		t.Errorf("rejected update was written: %q at version %d", stored.Title, stored.Version)
+	}
+}
+
+func updateTakenSlug(t *testing.T, f *fixture) {
+	f.create(t, "Taken", 0)
+	art := f.create(t, "Mine", 0)
+
+	_, err := f.Articles.Update(context.Background(), art.ID, &domain.Article{Title: "Taken", Slug: "taken"})
+	wantErr(t, "Update", err, domain.ErrSlugTaken)
+
+	if stored := f.find(t, art.ID); stored.Slug != "mine" || stored.Version != 1 {
+		t.Errorf("rejected update was written: %q at version %d", stored.Slug, stored.Version)
+	}
+	revisions, err := f.Articles.FetchRevisions(context.Background(), art.ID)
+	if err != nil || len(revisions) != 0 {
+		t.Errorf("rejected update left revisions %+v, %v", revisions, err)
+	}
+}
+
+func updateUnknown(t *testing.T, f *fixture) {
+	_, err := f.Articles.Update(context.Background(), uuid.New(), &domain.Article{Title: "Nothing"})
+	wantErr(t, "Update", err, domain.ErrNotFound)
+}
+
+func trash(t *testing.T, f *fixture) {
+	art := f.create(t, "Trash", 0)
+	ctx := context.Background()
+
+	wantErr(t, "Restore a live article", f.Articles.Restore(ctx, art.ID), domain.ErrNotFound)
+	wantErr(t, "ForceDelete a live article", f.Articles.ForceDelete(ctx, art.ID), domain.ErrNotFound)
+	wantConflict(t, "Delete a stale version", f.Articles.Delete(ctx, art.ID, 2), 1)
+
+	wantErr(t, "Delete", f.Articles.Delete(ctx, art.ID, 1), nil)
+	_, err := f.Articles.FindBy(ctx, "id", art.ID.String())
+	wantErr(t, "FindBy a trashed article", err, domain.ErrNotFound)
+	if stored := f.find(t, art.ID); stored.DeletedAt.IsZero() {
+		t.Error("trashed article has no deleted_at")
+	}
+	wantErr(t, "Delete a trashed article by version", f.Articles.Delete(ctx, art.ID, 1), domain.ErrNotFound)
+	wantErr(t, "Delete a trashed article", f.Articles.Delete(ctx, art.ID, 0), nil)
+	wantErr(t, "Delete an unknown article", f.Articles.Delete(ctx, uuid.New(), 0), nil)
+
+	wantErr(t, "Restore", f.Articles.Restore(ctx, art.ID), nil)
+	if _, err := f.Articles.FindBy(ctx, "id", art.ID.String()); err != nil {
+		t.Fatalf("FindBy a restored article: %v", err)
+	}
+
+	wantErr(t, "Delete again", f.Articles.Delete(ctx, art.ID, 0), nil)
+	wantErr(t, "ForceDelete", f.Articles.ForceDelete(ctx, art.ID), nil)
+	_, err = f.Articles.FindWithTrashed(ctx, art.ID)
+	wantErr(t, "FindWithTrashed a purged article", err, domain.ErrNotFound)
+	wantErr(t, "Restore a purged article", f.Articles.Restore(ctx, art.ID), domain.ErrNotFound)
+}
+
+func fetch(t *testing.T, f *fixture) {
+	for i, title := range []string{"Charlie", "Alpha", "Bravo"} {
+		f.publish(t, f.create(t, title, time.Duration(i+1)*time.Hour))
+	}
+	f.create(t, "Draft", 0)
+	gone := f.create(t, "Gone", 0)
+	if err := f.Articles.Delete(context.Background(), gone.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	other := author(t, f.Users)
+	tests := []struct {
+		name      string
+		filter    domain.ArticleFilter
+		want      []string
+		wantTotal int
+	}{
+		{name: "newest first", filter: domain.ArticleFilter{Order: "desc"},
+			want: []string{"Draft", "Charlie", "Alpha", "Bravo"}, wantTotal: 4},
+		{name: "published by title", filter: domain.ArticleFilter{Status: domain.ArticleStatusPublished, Sort: "title"},
+			want: []string{"Alpha", "Bravo", "Charlie"}, wantTotal: 3},
+		{name: "limit and offset", filter: domain.ArticleFilter{Sort: "title", Limit: 2, Offset: 1},
+			want: []string{"Bravo", "Charlie"}, wantTotal: 4},
+		{name: "title contains", filter: domain.ArticleFilter{Title: "RAV"},
+			want: []string{"Bravo"}, wantTotal: 1},
+		{name: "created from", filter: domain.ArticleFilter{Sort: "title", CreatedFrom: time.Now().UTC().Add(-150 * time.Minute)},
+			want: []string{"Alpha", "Charlie", "Draft"}, wantTotal: 3},
+		{name: "trashed only", filter: domain.ArticleFilter{Trashed: "only"},
+			want: []string{"Gone"}, wantTotal: 1},
+		{name: "with trashed", filter: domain.ArticleFilter{Trashed: "with", Sort: "title", Limit: 1},
+			want: []string{"Alpha"}, wantTotal: 5},
+		{name: "someone else's", filter: domain.ArticleFilter{AuthorID: other},
+			want: []string{}, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		filter := tt.filter
+		articles, total, err := f.Articles.Fetch(context.Background(), &filter, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := titles(articles); !reflect.DeepEqual(got, tt.want) || total != tt.wantTotal {
+			t.Errorf("%s: got %v of %d, want %v of %d", tt.name, got, total, tt.want, tt.wantTotal)
+		}
+	}
+}
+
+func fetchCursor(t *testing.T, f *fixture) {
+	articles := map[string]*domain.Article{}
+	for _, title := range []string{"Alpha", "Bravo", "Charlie", "Delta"} {
+		articles[title] = f.create(t, title, 0)
+	}
+	bravo, charlie := articles["Bravo"], articles["Charlie"]
+
+	tests := []struct {
+		name   string
+		order  string
+		cursor domain.ArticleCursor
+		want   []string
+	}{
+		{name: "forward", cursor: domain.ArticleCursor{Value: bravo.Title, ID: bravo.ID},
+			want: []string{"Charlie", "Delta"}},
+		{name: "backward", cursor: domain.ArticleCursor{Value: charlie.Title, ID: charlie.ID, Backward: true},
+			want: []string{"Alpha", "Bravo"}},
+		{name: "forward descending", order: "desc", cursor: domain.ArticleCursor{Value: charlie.Title, ID: charlie.ID},
+			want: []string{"Bravo", "Alpha"}},
+	}
+
+	for _, tt := range tests {
+		filter := domain.ArticleFilter{Sort: "title", Order: tt.order, Limit: 10}
+		cursor := tt.cursor
+		res, _, err := f.Articles.Fetch(context.Background(), &filter, &cursor)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := titles(res); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	filter := domain.ArticleFilter{Limit: 10}
+	_, _, err := f.Articles.Fetch(context.Background(), &filter, &domain.ArticleCursor{Value: "yesterday", ID: bravo.ID})
+	wantErr(t, "Fetch with a malformed time cursor", err, domain.ErrInvalidCursor)
+}
+
+func takenSlugs(t *testing.T, f *fixture) {
+	hello := f.create(t, "Hello", 0)
+	f.create(t, "Hello 2", 0)
+	f.create(t, "Helloworld", 0)
+	renamed := f.create(t, "Hello Old", 0)
+	if _, err := f.Articles.Update(context.Background(), renamed.ID, &domain.Article{Title: "Renamed", Slug: "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		exceptID uuid.UUID
+		want     []string
+	}{
+		{uuid.Nil, []string{"hello", "hello-2", "hello-old"}},
+		{hello.ID, []string{"hello-2", "hello-old"}},
+		{renamed.ID, []string{"hello", "hello-2"}},
+	}
+
+	for _, tt := range tests {
+		slugs, err := f.Articles.TakenSlugs(context.Background(), "hello", tt.exceptID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]bool{}
+		for _, slug := range slugs {
+			got[slug] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("TakenSlugs except %v = %v, want %v", tt.exceptID, slugs, tt.want)
+			continue
+		}
+		for _, slug := range tt.want {
+			if !got[slug] {
+				t.Errorf("TakenSlugs except %v = %v, want %v", tt.exceptID, slugs, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func setStatus(t *testing.T, f *fixture) {
+	art := f.create(t, "Status", 0)
+	at := time.Now().UTC().Truncate(time.Second)
+
+	err := f.Articles.SetStatus(context.Background(), art.ID, domain.ArticleStatusPublished, pg.NullTime{Time: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := f.find(t, art.ID)
+	if stored.Status != domain.ArticleStatusPublished || !stored.PublishedAt.Equal(at) || stored.Version != 2 {
+		t.Errorf("got %s at %v, version %d", stored.Status, stored.PublishedAt, stored.Version)
+	}
+
+	err = f.Articles.SetStatus(context.Background(), uuid.New(), domain.ArticleStatusPublished, pg.NullTime{Time: at})
+	wantErr(t, "SetStatus of an unknown article", err, domain.ErrNotFound)
+}
+
+func schedule(t *testing.T, f *fixture) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if _, ok, err := f.Articles.NextScheduled(ctx); err != nil || ok {
+		t.Fatalf("NextScheduled with nothing scheduled: %v, %v", ok, err)
+	}
+
+	due := f.create(t, "Due", 0)
+	later := f.create(t, "Later", 0)
+	for art, at := range map[*domain.Article]time.Time{due: now.Add(-time.Minute), later: now.Add(time.Hour)} {
+		if err := f.Articles.SetStatus(ctx, art.ID, domain.ArticleStatusScheduled, pg.NullTime{Time: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if next, ok, err := f.Articles.NextScheduled(ctx); err != nil || !ok || !next.Equal(now.Add(-time.Minute)) {
+		t.Errorf("NextScheduled = %v, %v, %v, want %v", next, ok, err, now.Add(-time.Minute))
+	}
+
+	published, err := f.Articles.PublishDue(ctx, now)
+	if err != nil || published != 1 {
+		t.Fatalf("PublishDue = %d, %v, want 1", published, err)
+	}
+	if stored := f.find(t, due.ID); stored.Status != domain.ArticleStatusPublished || stored.Version != 3 {
+		t.Errorf("due article is %s at version %d", stored.Status, stored.Version)
+	}
+	if stored := f.find(t, later.ID); stored.Status != domain.ArticleStatusScheduled {
+		t.Errorf("later article is %s", stored.Status)
+	}
+
+	if next, ok, err := f.Articles.NextScheduled(ctx); err != nil || !ok || !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("NextScheduled = %v, %v, %v, want %v", next, ok, err, now.Add(time.Hour))
+	}
+}
+
+func anonymize(t *testing.T, f *fixture) {
+	live := f.create(t, "Live", 0)
+	gone := f.create(t, "Gone", 0)
+	if err := f.Articles.Delete(context.Background(), gone.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	other := f.author
+	f.author = author(t, f.Users)
+	kept := f.create(t, "Kept", 0)
+
+	if err := f.Articles.Anonymize(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, art := range []*domain.Article{live, gone} {
+		if stored := f.find(t, art.ID); stored.AuthorID != uuid.Nil {
+			t.Errorf("%s still has author %v", art.Title, stored.AuthorID)
+		}
+	}
+	if stored := f.find(t, kept.ID); stored.AuthorID != f.author {
+		t.Errorf("article of another author lost its author")
+	}
+}
+
+func views(t *testing.T, f *fixture) {
+	ctx := context.Background()
+	today := time.Now().UTC()
+	hot := f.create(t, "Hot", 2*time.Hour)
+	warm := f.create(t, "Warm", time.Hour)
+	old := f.create(t, "Old", 0)
+	draft := f.create(t, "Draft", 0)
+	for _, art := range []*domain.Article{hot, warm, old} {
+		f.publish(t, art)
+	}
+
+	err := f.Articles.AddViews(ctx, []domain.ArticleViews{
+		{ArticleID: hot.ID, Day: today, Views: 5},
+		{ArticleID: hot.ID, Day: today.AddDate(0, 0, -1), Views: 5},
+		{ArticleID: warm.ID, Day: today, Views: 3},
+		{ArticleID: old.ID, Day: today.AddDate(0, 0, -30), Views: 100},
+		{ArticleID: draft.ID, Day: today, Views: 50},
+		{ArticleID: uuid.New(), Day: today, Views: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Articles.AddViews(ctx, []domain.ArticleViews{{ArticleID: warm.ID, Day: today, Views: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored := f.find(t, hot.ID); stored.ViewCount != 10 {
+		t.Errorf("view count = %d, want 10", stored.ViewCount)
+	}
+
+	popular, err := f.Articles.Popular(ctx, today.AddDate(0, 0, -6), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(popular))
+	for i, p := range popular {
+		got[i] = p.Title
+	}
+	if !reflect.DeepEqual(got, []string{"Hot", "Warm"}) || popular[0].RecentViews != 10 || popular[1].RecentViews != 4 {
+		t.Errorf("Popular = %+v, want Hot with 10 and Warm with 4 views", popular)
+	}
+
+	popular, err = f.Articles.Popular(ctx, today, 1)
+	if err != nil || len(popular) != 1 || popular[0].Title != "Hot" || popular[0].RecentViews != 5 {
+		t.Errorf("Popular today = %+v, %v, want Hot with 5 views", popular, err)
+	}
+}
+
+func search(t *testing.T, f *fixture) {
+	found := f.create(t, "Concurrency", 0)
+	found.Description = "Channels and goroutines <script>alert('x')</script>"
+	if _, err := f.Articles.Update(context.Background(), found.ID, &domain.Article{Description: found.Description}); err != nil {
+		t.Fatal(err)
+	}
+	f.publish(t, found)
+	f.create(t, "Goroutines draft", 0)
+
+	res, total, err := f.Articles.Search(context.Background(), &domain.ArticleSearch{Query: "goroutines", Language: "english", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(res) != 1 || res[0].ID != found.ID {
+		t.Fatalf("Search = %+v of %d, want %s only", res, total, found.Title)
+	}
+	if !strings.Contains(res[0].Headline, "<mark>goroutines</mark>") {
+		t.Errorf("headline %q does not mark the match", res[0].Headline)
+	}
+	if strings.Contains(res[0].Headline, "<script>") || !strings.Contains(res[0].Headline, "&lt;script&gt;") {
+		t.Errorf("headline %q is not escaped", res[0].Headline)
+	}
+
+	res, total, err = f.Articles.Search(context.Background(), &domain.ArticleSearch{Query: "rust", Language: "english", Limit: 10})
+	if err != nil || total != 0 || len(res) != 0 {
+		t.Errorf("Search without a match = %+v of %d, %v", res, total, err)
+	}
+}
+
+func searchUnknownLanguage(t *testing.T, f *fixture) {
+	_, _, err := f.Articles.Search(context.Background(), &domain.ArticleSearch{Query: "go", Language: "klingon", Limit: 10})
+	wantErr(t, "Search", err, domain.ErrUnknownLanguage)
+}
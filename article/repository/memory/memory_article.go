@@ -0,0 +1,725 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// searchLanguages are the text search configurations PostgreSQL ships with.
+var searchLanguages = map[string]bool{
+	"simple": true, "arabic": true, "armenian": true, "basque": true, "catalan": true,
+	"danish": true, "dutch": true, "english": true, "finnish": true, "french": true,
+	"german": true, "greek": true, "hindi": true, "hungarian": true, "indonesian": true,
+	"irish": true, "italian": true, "lithuanian": true, "nepali": true, "norwegian": true,
+	"portuguese": true, "romanian": true, "russian": true, "serbian": true, "spanish": true,
+	"swedish": true, "tamil": true, "turkish": true, "yiddish": true,
+}
+
+// memoryArticleRepository keeps articles, their old slugs, revisions and views in maps.
+// It answers like psqlArticleRepository does, with domain.ErrNotFound for missing articles and
+// ErrSlugTaken when a slug is in use. Full-text search is approximated by looking for
+// every word of the query, and listings can not be filtered by tag.
+type memoryArticleRepository struct {
+	mu        sync.RWMutex
+	articles  map[uuid.UUID]domain.Article
+	oldSlugs  map[string]domain.ArticleSlug
+	revisions map[uuid.UUID][]domain.ArticleRevision
+	views     map[uuid.UUID]map[string]int64
+}
+
+func NewMemoryArticleRepository() domain.ArticleRepository {
+	return &memoryArticleRepository{
+		articles:  make(map[uuid.UUID]domain.Article),
+		oldSlugs:  make(map[string]domain.ArticleSlug),
+		revisions: make(map[uuid.UUID][]domain.ArticleRevision),
+		views:     make(map[uuid.UUID]map[string]int64),
+	}
+}
+
+func (m *memoryArticleRepository) Create(ctx context.Context, ar *domain.Article) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.articles[ar.ID]; ok {
+		return domain.ErrConflict
+	}
+	if m.slugUsed(ar.Slug, uuid.Nil) {
+		return domain.ErrSlugTaken
+	}
+
+	// Zero values are left to the column defaults.
+	row := stored(ar)
+	if row.Language == "" {
+		row.Language = "english"
+	}
+	if row.Status == "" {
+		row.Status = domain.ArticleStatusDraft
+	}
+	if row.Version == 0 {
+		row.Version = 1
+	}
+
+	m.articles[ar.ID] = row
+	return nil
+}
+
+func (m *memoryArticleRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	art, ok := m.live(id)
+	if version > 0 && (!ok || art.Version != version) {
+		if !ok {
+			return domain.ErrNotFound
+		}
+		return &domain.VersionConflictError{Current: art.Version}
+	}
+	if !ok {
+		return nil
+	}
+
+	art.DeletedAt = pg.NullTime{Time: time.Now()}
+	m.articles[id] = art
+	return nil
+}
+
+func (m *memoryArticleRepository) Fetch(ctx context.Context, filter *domain.ArticleFilter, cursor *domain.ArticleCursor) (res []domain.Article, total int, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	title := strings.ToLower(filter.Title)
+	var articles []domain.Article
+	for _, art := range m.articles {
+		switch {
+		case filter.Trashed == "only" && art.DeletedAt.IsZero(),
+			filter.Trashed != "with" && filter.Trashed != "only" && !art.DeletedAt.IsZero(),
+			filter.AuthorID != uuid.Nil && art.AuthorID != filter.AuthorID,
+			filter.Status != "" && art.Status != filter.Status,
+			filter.Tag != "",
+			title != "" && !strings.Contains(strings.ToLower(art.Title), title),
+			!filter.CreatedFrom.IsZero() && art.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !art.CreatedAt.Before(filter.CreatedTo):
+			continue
+		}
+		articles = append(articles, art)
+	}
+	total = len(articles)
+
+	column := filter.Sort
+	if column != "updated_at" && column != "title" {
+		column = "created_at"
+	}
+
+	desc := strings.EqualFold(filter.Order, "desc")
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+
+	sort.Slice(articles, func(i, j int) bool {
+		c := compareArticles(&articles[i], &articles[j], column)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if cursor != nil {
+		after := domain.Article{ID: cursor.ID, Title: cursor.Value}
+		if column != "title" {
+			value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return nil, 0, domain.ErrInvalidCursor
+			}
+			after.CreatedAt, after.UpdatedAt = value, value
+		}
+
+		kept := articles[:0]
+		for _, art := range articles {
+			c := compareArticles(&art, &after, column)
+			if (desc && c < 0) || (!desc && c > 0) {
+				kept = append(kept, art)
+			}
+		}
+		articles = kept
+	} else {
+		articles = page(articles, 0, filter.Offset)
+	}
+	articles = page(articles, filter.Limit, 0)
+
+	// A backward page is read in reverse, flip it back.
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(articles)-1; i < j; i, j = i+1, j-1 {
+			articles[i], articles[j] = articles[j], articles[i]
+		}
+	}
+
+	return articles, total, nil
+}
+
+func (m *memoryArticleRepository) FindBy(ctx context.Context, key, value string) (ar *domain.Article, err error) {
+	column := strings.TrimSpace(key)
+	if !articleColumns[column] {
+		return nil, fmt.Errorf("ERROR #42703 column %q does not exist", column)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *domain.Article
+	for _, art := range m.articles {
+		if !art.DeletedAt.IsZero() || articleColumn(&art, column) != value {
+			continue
+		}
+		// First orders by primary key.
+		if found == nil || art.ID.String() < found.ID.String() {
+			art := art
+			found = &art
+		}
+	}
+	if found == nil {
+		return nil, domain.ErrNotFound
+	}
+	return found, nil
+}
+
+func (m *memoryArticleRepository) FindByOldSlug(ctx context.Context, slug string) (ar *domain.Article, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	old, ok := m.oldSlugs[slug]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	art, ok := m.live(old.ArticleID)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &art, nil
+}
+
+func (m *memoryArticleRepository) TakenSlugs(ctx context.Context, base string, exceptID uuid.UUID) (res []string, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := func(slug string) bool {
+		return slug == base || strings.HasPrefix(slug, base+"-")
+	}
+
+	seen := make(map[string]bool)
+	for id, art := range m.articles {
+		if id != exceptID && matches(art.Slug) {
+			seen[art.Slug] = true
+		}
+	}
+	for slug, old := range m.oldSlugs {
+		if old.ArticleID != exceptID && matches(slug) {
+			seen[slug] = true
+		}
+	}
+
+	for slug := range seen {
+		res = append(res, slug)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (m *memoryArticleRepository) FindWithTrashed(ctx context.Context, id uuid.UUID) (ar *domain.Article, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	art, ok := m.articles[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &art, nil
+}
+
+func (m *memoryArticleRepository) ForceDelete(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	art, ok := m.articles[id]
+	if !ok || art.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+
+	// What the foreign keys cascade to.
+	delete(m.articles, id)
+	delete(m.revisions, id)
+	delete(m.views, id)
+	for slug, old := range m.oldSlugs {
+		if old.ArticleID == id {
+			delete(m.oldSlugs, slug)
+		}
+	}
+	return nil
+}
+
+func (m *memoryArticleRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	art, ok := m.articles[id]
+	if !ok || art.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+
+	art.DeletedAt = pg.NullTime{}
+	m.articles[id] = art
+	return nil
+}
+
+func (m *memoryArticleRepository) Search(ctx context.Context, search *domain.ArticleSearch) (res []domain.ArticleSearchResult, total int, err error) {
+	if !searchLanguages[search.Language] {
+		return nil, 0, domain.ErrUnknownLanguage
+	}
+
+	terms := words(search.Query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	m.mu.RLock()
+	for _, art := range m.articles {
+		if !art.DeletedAt.IsZero() || art.Status != domain.ArticleStatusPublished {
+			continue
+		}
+
+		rank := 0
+		text := words(art.Title + " " + art.Description)
+		for _, term := range terms {
+			hits := 0
+			for _, word := range text {
+				if word == term {
+					hits++
+				}
+			}
+			if hits == 0 {
+				rank = 0
+				break
+			}
+			rank += hits
+		}
+		if rank == 0 {
+			continue
+		}
+
+		res = append(res, domain.ArticleSearchResult{
+			Article:       art,
+			Rank:          float64(rank) / float64(len(text)),
+			TitleHeadline: highlight(art.Title, terms),
+			Headline:      highlight(art.Description, terms),
+		})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Rank != res[j].Rank {
+			return res[i].Rank > res[j].Rank
+		}
+		return compareArticles(&res[i].Article, &res[j].Article, "created_at") > 0
+	})
+
+	total = len(res)
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	offset := search.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(res) {
+		return []domain.ArticleSearchResult{}, total, nil
+	}
+	res = res[offset:]
+	if search.Limit > 0 && search.Limit < len(res) {
+		res = res[:search.Limit]
+	}
+	return res, total, nil
+}
+
+func (m *memoryArticleRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, publishedAt pg.NullTime) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	art, ok := m.live(id)
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	art.Status = status
+	art.PublishedAt = publishedAt
+	art.UpdatedAt = time.Now()
+	art.Version++
+	m.articles[id] = art
+	return nil
+}
+
+func (m *memoryArticleRepository) PublishDue(ctx context.Context, now time.Time) (published int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, art := range m.articles {
+		if !art.DeletedAt.IsZero() || art.Status != domain.ArticleStatusScheduled || art.PublishedAt.After(now) {
+			continue
+		}
+		art.Status = domain.ArticleStatusPublished
+		art.Version++
+		m.articles[id] = art
+		published++
+	}
+	return published, nil
+}
+
+func (m *memoryArticleRepository) NextScheduled(ctx context.Context) (at time.Time, ok bool, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, art := range m.articles {
+		if !art.DeletedAt.IsZero() || art.Status != domain.ArticleStatusScheduled || art.PublishedAt.IsZero() {
+			continue
+		}
+		if !ok || art.PublishedAt.Before(at) {
+			at, ok = art.PublishedAt.Time, true
+		}
+	}
+	return at, ok, nil
+}
+
+func (m *memoryArticleRepository) Anonymize(ctx context.Context, authorID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, art := range m.articles {
+		if art.AuthorID == authorID {
+			art.AuthorID = uuid.Nil
+			m.articles[id] = art
+		}
+	}
+	return nil
+}
+
+func (m *memoryArticleRepository) Update(ctx context.Context, id uuid.UUID, art *domain.Article) (ar *domain.Article, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.live(id)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if art.Version > 0 && art.Version != current.Version {
+		return nil, &domain.VersionConflictError{Current: current.Version}
+	}
+	if art.Slug != "" && art.Slug != current.Slug && m.slugUsed(art.Slug, id) {
+		return nil, domain.ErrSlugTaken
+	}
+
+	revisions := m.revisions[id]
+	m.revisions[id] = append(revisions, domain.ArticleRevision{
+		ID:          uuid.New(),
+		ArticleID:   id,
+		Revision:    len(revisions) + 1,
+		Title:       current.Title,
+		Description: current.Description,
+		EditorID:    current.UpdatedBy,
+		CreatedAt:   current.UpdatedAt,
+	})
+
+	if art.Slug != "" && art.Slug != current.Slug {
+		// The article may be getting back a slug it had before.
+		delete(m.oldSlugs, art.Slug)
+		m.oldSlugs[current.Slug] = domain.ArticleSlug{Slug: current.Slug, ArticleID: id, CreatedAt: time.Now()}
+	}
+
+	art.Version = current.Version + 1
+	m.articles[id] = merge(current, art)
+	return art, nil
+}
+
+func (m *memoryArticleRepository) FetchRevisions(ctx context.Context, articleID uuid.UUID) (res []domain.ArticleRevision, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := m.revisions[articleID]
+	for i := len(revisions) - 1; i >= 0; i-- {
+		res = append(res, revisions[i])
+	}
+	return res, nil
+}
+
+func (m *memoryArticleRepository) FindRevision(ctx context.Context, articleID uuid.UUID, revision int) (rev *domain.ArticleRevision, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, stored := range m.revisions[articleID] {
+		if stored.Revision == revision {
+			return &stored, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memoryArticleRepository) AddViews(ctx context.Context, views []domain.ArticleViews) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range views {
+		// Articles purged since they were read are skipped.
+		art, ok := m.articles[v.ArticleID]
+		if !ok {
+			continue
+		}
+
+		daily := m.views[v.ArticleID]
+		if daily == nil {
+			daily = make(map[string]int64)
+			m.views[v.ArticleID] = daily
+		}
+		daily[v.Day.Format("2006-01-02")] += v.Views
+
+		art.ViewCount += v.Views
+		m.articles[v.ArticleID] = art
+	}
+	return nil
+}
+
+func (m *memoryArticleRepository) Popular(ctx context.Context, since time.Time, limit int) (res []domain.PopularArticle, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from := since.Format("2006-01-02")
+	for id, daily := range m.views {
+		art, ok := m.live(id)
+		if !ok || art.Status != domain.ArticleStatusPublished {
+			continue
+		}
+
+		var recent int64
+		for day, views := range daily {
+			if day >= from {
+				recent += views
+			}
+		}
+		if recent > 0 {
+			res = append(res, domain.PopularArticle{Article: art, RecentViews: recent})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.RecentViews != b.RecentViews {
+			return a.RecentViews > b.RecentViews
+		}
+		if !a.PublishedAt.Equal(b.PublishedAt.Time) {
+			return a.PublishedAt.After(b.PublishedAt.Time)
+		}
+		return a.ID.String() > b.ID.String()
+	})
+
+	if limit > 0 && limit < len(res) {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// live returns the article unless it is missing or trashed. The caller holds the lock.
+func (m *memoryArticleRepository) live(id uuid.UUID) (domain.Article, bool) {
+	art, ok := m.articles[id]
+	if !ok || !art.DeletedAt.IsZero() {
+		return domain.Article{}, false
+	}
+	return art, true
+}
+
+// slugUsed tells whether an article other than exceptID, trashed ones included, has
+// slug. Like the unique index it ignores old slugs. The caller holds the lock.
+func (m *memoryArticleRepository) slugUsed(slug string, exceptID uuid.UUID) bool {
+	for id, art := range m.articles {
+		if id != exceptID && art.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// articleColumns are the columns FindBy can look articles up by.
+var articleColumns = map[string]bool{
+	"id":        true,
+	"slug":      true,
+	"title":     true,
+	"author_id": true,
+	"status":    true,
+}
+
+func articleColumn(art *domain.Article, column string) string {
+	switch column {
+	case "id":
+		return art.ID.String()
+	case "title":
+		return art.Title
+	case "author_id":
+		return art.AuthorID.String()
+	case "status":
+		return art.Status
+	default:
+		return art.Slug
+	}
+}
+
+// compareArticles orders articles by column and then id, as the listing does.
+func compareArticles(a, b *domain.Article, column string) int {
+	var c int
+	switch column {
+	case "title":
+		c = strings.Compare(a.Title, b.Title)
+	case "updated_at":
+		c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+	default:
+		c = compareTimes(a.CreatedAt, b.CreatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID.String(), b.ID.String())
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// stored copies ar the way it ends up in the table, without what is loaded from elsewhere.
+func stored(ar *domain.Article) domain.Article {
+	row := *ar
+	row.Tags = nil
+	row.Cover = nil
+	return row
+}
+
+// merge applies the non-zero fields of art to current, as UpdateNotZero does.
+func merge(current domain.Article, art *domain.Article) domain.Article {
+	if art.Title != "" {
+		current.Title = art.Title
+	}
+	if art.Slug != "" {
+		current.Slug = art.Slug
+	}
+	if art.Description != "" {
+		current.Description = art.Description
+	}
+	if art.DescriptionHTML != "" {
+		current.DescriptionHTML = art.DescriptionHTML
+	}
+	if art.Excerpt != "" {
+		current.Excerpt = art.Excerpt
+	}
+	if art.ReadingTime != 0 {
+		current.ReadingTime = art.ReadingTime
+	}
+	if art.AuthorID != uuid.Nil {
+		current.AuthorID = art.AuthorID
+	}
+	if art.Language != "" {
+		current.Language = art.Language
+	}
+	if art.Status != "" {
+		current.Status = art.Status
+	}
+	if !art.PublishedAt.IsZero() {
+		current.PublishedAt = art.PublishedAt
+	}
+	if art.UpdatedBy != uuid.Nil {
+		current.UpdatedBy = art.UpdatedBy
+	}
+	if art.CoverID != uuid.Nil {
+		current.CoverID = art.CoverID
+	}
+	if art.ViewCount != 0 {
+		current.ViewCount = art.ViewCount
+	}
+	if art.Version != 0 {
+		current.Version = art.Version
+	}
+	if !art.CreatedAt.IsZero() {
+		current.CreatedAt = art.CreatedAt
+	}
+	if !art.UpdatedAt.IsZero() {
+		current.UpdatedAt = art.UpdatedAt
+	}
+	if !art.DeletedAt.IsZero() {
+		current.DeletedAt = art.DeletedAt
+	}
+	return current
+}
+
+// page cuts out the rows LIMIT and OFFSET would return, a limit of 0 means no limit.
+func page(articles []domain.Article, limit, offset int) []domain.Article {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(articles) {
+		return nil
+	}
+	articles = articles[offset:]
+	if limit > 0 && limit < len(articles) {
+		articles = articles[:limit]
+	}
+	return articles
+}
+
+// words splits text into lower case words, the way the search looks at it.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// highlight marks the words of text that are search terms like ts_headline does, after
+// escaping text for HTML like the PostgreSQL repository.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := html.EscapeString(text[start:end])
+		for _, term := range terms {
+			if strings.ToLower(text[start:end]) == term {
+				word = "<mark>" + word + "</mark>"
+				break
+			}
+		}
+		b.WriteString(word)
+		start = -1
+	}
+
+	for i, r := range text {
+		letter := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if letter && start < 0 {
+			start = i
+		} else if !letter {
+			if start >= 0 {
+				flush(i)
+			}
+			b.WriteString(html.EscapeString(string(r)))
+		}
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
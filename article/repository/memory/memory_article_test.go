@@ -0,0 +1,17 @@
+package memory_test
+
+import (
+	"go-boilerplate/article/repository/contract"
+	"go-boilerplate/article/repository/memory"
+	_userMemoryRepository "go-boilerplate/user/repository/memory"
+	"testing"
+)
+
+func TestArticleRepository(t *testing.T) {
+	contract.ArticleRepository(t, func(t *testing.T) contract.Repositories {
+		return contract.Repositories{
+			Articles: memory.NewMemoryArticleRepository(),
+			Users:    _userMemoryRepository.NewMemoryUserRepository(),
+		}
+	})
+}
@@ -2,54 +2,497 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/google/uuid"
-	"github.com/sirupsen/logrus"
+	database "go-boilerplate/db/postgresql"
 	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+	"strings"
+	"time"
 )
 
+// sortColumns are the columns an article listing may be ordered by.
+var sortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+}
+
 type psqlArticleRepository struct {
-	DB *pg.DB
+	DB orm.DB
 }
 
-func NewPsqlArticleRepository(db *pg.DB) domain.ArticleRepository{
+func NewPsqlArticleRepository(db orm.DB) domain.ArticleRepository {
 	return psqlArticleRepository{DB: db}
 }
 
+// Create inserts ar. Within a Transactor's transaction it runs in a savepoint, so a
+// taken slug can be retried without aborting the transaction.
 func (p psqlArticleRepository) Create(ctx context.Context, ar *domain.Article) error {
-	_, err := p.DB.Model(ar).Insert()
-	if err != nil {
-		logrus.Warnln(err)
+	err := database.RunInTransaction(ctx, database.Conn(ctx, p.DB), func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, ar).Insert()
 		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
 	}
 	return nil
 }
 
-func (p psqlArticleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (p psqlArticleRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
 	article := new(domain.Article)
-	_, err := p.DB.Model(article).Where("id=?", id).Delete()
+	query := database.Conn(ctx, p.DB).ModelContext(ctx, article).Where("id=?", id)
+	if version > 0 {
+		query.Where("version = ?", version)
+	}
+
+	res, err := query.Delete()
 	if err != nil {
-		logrus.Warnln(err)
-		return err
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if version > 0 && res.RowsAffected() == 0 {
+		return p.conflict(ctx, id)
 	}
 	return nil
 }
 
+// conflict reports the version the article is at now, or domain.ErrNotFound when it is gone.
+func (p psqlArticleRepository) conflict(ctx context.Context, id uuid.UUID) error {
+	current := new(domain.Article)
+	if err := p.DB.ModelContext(ctx, current).Column("version").Where("id = ?", id).Select(); err != nil {
+		return translateError(err)
+	}
+	return &domain.VersionConflictError{Current: current.Version}
+}
+
+func (p psqlArticleRepository) Fetch(ctx context.Context, filter *domain.ArticleFilter, cursor *domain.ArticleCursor) (res []domain.Article, total int, err error) {
+	var articles []domain.Article
+	query := p.DB.ModelContext(ctx, &articles)
+
+	switch filter.Trashed {
+	case "with":
+		query.AllWithDeleted()
+	case "only":
+		query.Deleted()
+	}
+
+	if filter.AuthorID != uuid.Nil {
+		query.Where("author_id = ?", filter.AuthorID)
+	}
+	if filter.Status != "" {
+		query.Where("status = ?", filter.Status)
+	}
+	if filter.Tag != "" {
+		query.Where(`EXISTS (SELECT 1 FROM article_tags JOIN tags ON tags.id = article_tags.tag_id
+			WHERE article_tags.article_id = article.id AND tags.slug = ?)`, filter.Tag)
+	}
+	if filter.Title != "" {
+		query.Where("title ILIKE ?", "%"+escapeLike(filter.Title)+"%")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	total, err = query.Count()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, translateError(err)
+	}
+
+	column := filter.Sort
+	if !sortColumns[column] {
+		column = "created_at"
+	}
+
+	desc := strings.EqualFold(filter.Order, "desc")
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+
+	direction, compare := "ASC", ">"
+	if desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var value interface{} = cursor.Value
+		if column != "title" {
+			if value, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+				return nil, 0, domain.ErrInvalidCursor
+			}
+		}
+		query.Where("(?, article.id) "+compare+" (?, ?)", pg.Ident(column), value, cursor.ID)
+	} else {
+		query.Offset(filter.Offset)
+	}
+
+	err = query.OrderExpr("? "+direction+", article.id "+direction, pg.Ident(column)).
+		Limit(filter.Limit).
+		Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, translateError(err)
+	}
+
+	// A backward page is read in reverse so the keyset condition can use the index, flip it back.
+	if cursor != nil && cursor.Backward {
+		for i, j := 0, len(articles)-1; i < j; i, j = i+1, j-1 {
+			articles[i], articles[j] = articles[j], articles[i]
+		}
+	}
+
+	return articles, total, nil
+}
+
 func (p psqlArticleRepository) FindBy(ctx context.Context, key, value string) (ar *domain.Article, err error) {
 	ar = new(domain.Article)
 	if err := p.DB.Model(ar).Where(key+"=?", value).First(); err != nil {
-		return nil, err
-		logrus.Warnln(err)
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 	return ar, nil
 }
 
+func (p psqlArticleRepository) FindByOldSlug(ctx context.Context, slug string) (ar *domain.Article, err error) {
+	ar = new(domain.Article)
+	err = p.DB.ModelContext(ctx, ar).
+		Join("JOIN article_slugs ON article_slugs.article_id = article.id").
+		Where("article_slugs.slug = ?", slug).
+		First()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return ar, nil
+}
+
+func (p psqlArticleRepository) TakenSlugs(ctx context.Context, base string, exceptID uuid.UUID) (res []string, err error) {
+	// Trashed articles keep their slug reserved so they can be restored.
+	_, err = p.DB.QueryContext(ctx, &res, `
+		SELECT slug FROM articles WHERE (slug = ?0 OR slug LIKE ?1) AND id != ?2
+		UNION
+		SELECT slug FROM article_slugs WHERE (slug = ?0 OR slug LIKE ?1) AND article_id != ?2`,
+		base, escapeLike(base)+"-%", exceptID)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return res, nil
+}
+
+// articleColumns are selected by the hand written queries that scan into an Article.
+const articleColumns = `article.id, article.title, article.slug, article.description, article.description_html,
+	article.excerpt, article.reading_time, article.cover_id, article.view_count, article.author_id, article.language,
+	article.status, article.published_at, article.updated_by, article.version, article.created_at,
+	article.updated_at, article.deleted_at`
+
+// searchOptions controls the snippets ts_headline cuts out of the description.
+const searchOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+// escapeHTML escapes the text in column for HTML the way html.EscapeString does. The
+// headlines are cut from escaped text, so the highlighting is the only markup in them.
+func escapeHTML(column string) string {
+	return `replace(replace(replace(replace(replace(` + column +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+}
+
+// searchParams are the named parameters of the search queries.
+type searchParams struct {
+	*domain.ArticleSearch
+	Status  string
+	Options string
+}
+
+var (
+	searchCountQuery = `
+		SELECT count(*) FROM articles AS article
+		WHERE article.deleted_at IS NULL AND article.status = ?status
+			AND article.search_vector @@ websearch_to_tsquery(?language::regconfig, ?query)`
+
+	searchQuery = `
+		SELECT ` + articleColumns + `,
+			ts_rank(article.search_vector, query) AS rank,
+			ts_headline(article.language, ` + escapeHTML("article.title") + `, query, 'HighlightAll=true') AS title_headline,
+			ts_headline(article.language, ` + escapeHTML("article.description") + `, query, ?options) AS headline
+		FROM articles AS article, websearch_to_tsquery(?language::regconfig, ?query) AS query
+		WHERE article.deleted_at IS NULL AND article.status = ?status AND article.search_vector @@ query
+		ORDER BY rank DESC, article.created_at DESC, article.id DESC
+		LIMIT ?limit OFFSET ?offset`
+)
+
+func (p psqlArticleRepository) Search(ctx context.Context, search *domain.ArticleSearch) (res []domain.ArticleSearchResult, total int, err error) {
+	params := &searchParams{ArticleSearch: search, Status: domain.ArticleStatusPublished, Options: searchOptions}
+
+	_, err = p.DB.QueryOneContext(ctx, pg.Scan(&total), searchCountQuery, params)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, translateError(err)
+	}
+
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	_, err = p.DB.QueryContext(ctx, &res, searchQuery, params)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, translateError(err)
+	}
+	return res, total, nil
+}
+
+func (p psqlArticleRepository) FindWithTrashed(ctx context.Context, id uuid.UUID) (ar *domain.Article, err error) {
+	ar = new(domain.Article)
+	if err := p.DB.ModelContext(ctx, ar).AllWithDeleted().Where("id = ?", id).First(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return ar, nil
+}
+
+// Restore brings a soft deleted article back.
+func (p psqlArticleRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	res, err := p.DB.ModelContext(ctx, (*domain.Article)(nil)).
+		Deleted().
+		Set("deleted_at = NULL").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (p psqlArticleRepository) Anonymize(ctx context.Context, authorID uuid.UUID) error {
+	_, err := database.Conn(ctx, p.DB).ModelContext(ctx, (*domain.Article)(nil)).
+		AllWithDeleted().
+		Set("author_id = NULL").
+		Where("author_id = ?", authorID).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (p psqlArticleRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, publishedAt pg.NullTime) error {
+	res, err := p.DB.ModelContext(ctx, (*domain.Article)(nil)).
+		Set("status = ?", status).
+		Set("published_at = ?", publishedAt).
+		Set("updated_at = ?", time.Now()).
+		Set("version = version + 1").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (p psqlArticleRepository) PublishDue(ctx context.Context, now time.Time) (published int, err error) {
+	res, err := p.DB.ModelContext(ctx, (*domain.Article)(nil)).
+		Set("status = ?", domain.ArticleStatusPublished).
+		Set("version = version + 1").
+		Where("status = ?", domain.ArticleStatusScheduled).
+		Where("published_at <= ?", now).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return 0, translateError(err)
+	}
+	return res.RowsAffected(), nil
+}
+
+func (p psqlArticleRepository) NextScheduled(ctx context.Context) (at time.Time, ok bool, err error) {
+	var next pg.NullTime
+	err = p.DB.ModelContext(ctx, (*domain.Article)(nil)).
+		ColumnExpr("min(published_at)").
+		Where("status = ?", domain.ArticleStatusScheduled).
+		Select(pg.Scan(&next))
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return time.Time{}, false, translateError(err)
+	}
+	return next.Time, !next.IsZero(), nil
+}
+
+// ForceDelete permanently removes an article that has already been soft deleted.
+func (p psqlArticleRepository) ForceDelete(ctx context.Context, id uuid.UUID) error {
+	res, err := p.DB.ModelContext(ctx, (*domain.Article)(nil)).Where("id = ?", id).ForceDelete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Update saves the non-zero fields of art after snapshotting the current content as
+// a revision. When the slug changes the old one is kept in article_slugs so links to
+// it can be redirected.
 func (p psqlArticleRepository) Update(ctx context.Context, id uuid.UUID, art *domain.Article) (ar *domain.Article, err error) {
-	_, err = p.DB.Model(art).Where("id = ?", id).UpdateNotZero()
+	expected := art.Version
+	err = database.RunInTransaction(ctx, database.Conn(ctx, p.DB), func(tx *pg.Tx) error {
+		current := new(domain.Article)
+		if err := tx.ModelContext(ctx, current).Where("id = ?", id).For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		if expected > 0 && expected != current.Version {
+			return &domain.VersionConflictError{Current: current.Version}
+		}
+		art.Version = current.Version + 1
+
+		revision := &domain.ArticleRevision{
+			ID:          uuid.New(),
+			ArticleID:   id,
+			Title:       current.Title,
+			Description: current.Description,
+			EditorID:    current.UpdatedBy,
+			CreatedAt:   current.UpdatedAt,
+		}
+		_, err := tx.QueryOneContext(ctx, pg.Scan(&revision.Revision),
+			"SELECT coalesce(max(revision), 0) + 1 FROM article_revisions WHERE article_id = ?", id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ModelContext(ctx, revision).Insert(); err != nil {
+			return err
+		}
+
+		if _, err := tx.ModelContext(ctx, art).Where("id = ?", id).UpdateNotZero(); err != nil {
+			return err
+		}
+
+		if art.Slug == "" || art.Slug == current.Slug {
+			return nil
+		}
+
+		// The article may be getting back a slug it had before.
+		if _, err := tx.ExecContext(ctx, "DELETE FROM article_slugs WHERE slug = ? AND article_id = ?", art.Slug, id); err != nil {
+			return err
+		}
+
+		_, err = tx.ModelContext(ctx, &domain.ArticleSlug{Slug: current.Slug, ArticleID: id, CreatedAt: time.Now()}).
+			OnConflict("(slug) DO UPDATE").
+			Set("article_id = EXCLUDED.article_id, created_at = EXCLUDED.created_at").
+			Insert()
+		return err
+	})
 	if err != nil {
-		logrus.Warnln(err)
-		return nil, err
+		// Leave art as it was passed in, the caller may retry with it.
+		art.Version = expected
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 	return art, nil
 }
 
+func (p psqlArticleRepository) FetchRevisions(ctx context.Context, articleID uuid.UUID) (res []domain.ArticleRevision, err error) {
+	err = p.DB.ModelContext(ctx, &res).
+		Where("article_id = ?", articleID).
+		Order("revision DESC").
+		Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return res, nil
+}
+
+func (p psqlArticleRepository) FindRevision(ctx context.Context, articleID uuid.UUID, revision int) (rev *domain.ArticleRevision, err error) {
+	rev = new(domain.ArticleRevision)
+	err = p.DB.ModelContext(ctx, rev).
+		Where("article_id = ?", articleID).
+		Where("revision = ?", revision).
+		Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return rev, nil
+}
+
+func (p psqlArticleRepository) AddViews(ctx context.Context, views []domain.ArticleViews) error {
+	err := database.RunInTransaction(ctx, p.DB, func(tx *pg.Tx) error {
+		for _, v := range views {
+			// Articles purged since they were read are skipped rather than failing the batch.
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO article_views_daily (article_id, day, views)
+				SELECT ?0, ?1, ?2 WHERE EXISTS (SELECT 1 FROM articles WHERE id = ?0)
+				ON CONFLICT (article_id, day) DO UPDATE SET views = article_views_daily.views + EXCLUDED.views`,
+				v.ArticleID, v.Day.Format("2006-01-02"), v.Views)
+			if err != nil {
+				return err
+			}
+			if res.RowsAffected() == 0 {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, "UPDATE articles SET view_count = view_count + ? WHERE id = ?", v.Views, v.ArticleID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (p psqlArticleRepository) Popular(ctx context.Context, since time.Time, limit int) (res []domain.PopularArticle, err error) {
+	_, err = p.DB.QueryContext(ctx, &res, `
+		SELECT `+articleColumns+`, recent.views AS recent_views
+		FROM (
+			SELECT article_id, sum(views) AS views FROM article_views_daily
+			WHERE day >= ? GROUP BY article_id
+		) AS recent
+		JOIN articles AS article ON article.id = recent.article_id
+		WHERE article.deleted_at IS NULL AND article.status = ?
+		ORDER BY recent.views DESC, article.published_at DESC, article.id DESC
+		LIMIT ?`,
+		since.Format("2006-01-02"), domain.ArticleStatusPublished, limit)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return res, nil
+}
+
+// uniques are the domain errors a taken unique column of the article tables is reported as.
+var uniques = map[string]error{
+	"articles_slug_unique": domain.ErrSlugTaken,
+}
+
+// translateError turns PostgreSQL errors the usecase can act on into domain errors.
+func translateError(err error) error {
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == "42704" && strings.Contains(pgErr.Field('M'), "text search configuration") {
+		return domain.ErrUnknownLanguage
+	}
+	return database.TranslateError(err, uniques)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
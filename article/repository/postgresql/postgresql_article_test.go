@@ -0,0 +1,24 @@
+package postgresql_test
+
+import (
+	"go-boilerplate/article/repository/contract"
+	"go-boilerplate/article/repository/postgresql"
+	"go-boilerplate/db/dbtest"
+	_userPostgreRepository "go-boilerplate/user/repository/postgresql"
+	"testing"
+)
+
+func TestArticleRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	if db == nil {
+		t.Skip("TEST_DATABASE_URL is not set, the contract runs against the in-memory repository only")
+	}
+
+	contract.ArticleRepository(t, func(t *testing.T) contract.Repositories {
+		tx := dbtest.Tx(t, db)
+		return contract.Repositories{
+			Articles: postgresql.NewPsqlArticleRepository(tx),
+			Users:    _userPostgreRepository.NewPsqlUserRepository(tx),
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package postgresql
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	"go-boilerplate/domain"
+	"strings"
+	"testing"
+)
+
+func TestSearchQueryBindsEachParameterOnce(t *testing.T) {
+	params := &searchParams{
+		ArticleSearch: &domain.ArticleSearch{Query: "goroutines", Language: "english", Limit: 10, Offset: 20},
+		Status:        domain.ArticleStatusPublished,
+		Options:       searchOptions,
+	}
+
+	for name, query := range map[string]string{"count": searchCountQuery, "results": searchQuery} {
+		sql := string(orm.NewFormatter().FormatQuery(nil, query, params))
+		for _, want := range []string{
+			"article.status = 'published'",
+			"websearch_to_tsquery('english'::regconfig, 'goroutines')",
+		} {
+			if !strings.Contains(sql, want) {
+				t.Errorf("%s query lacks %s:\n%s", name, want, sql)
+			}
+		}
+		if strings.Contains(sql, "?") {
+			t.Errorf("%s query has unbound parameters:\n%s", name, sql)
+		}
+	}
+
+	sql := string(orm.NewFormatter().FormatQuery(nil, searchQuery, params))
+	if !strings.Contains(sql, "'"+searchOptions+"') AS headline") || !strings.Contains(sql, "LIMIT 10 OFFSET 20") {
+		t.Errorf("results query binds the options or the page wrongly:\n%s", sql)
+	}
+}
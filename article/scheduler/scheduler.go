@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+	"time"
+)
+
+const (
+	defaultMaxWait = time.Minute
+	minWait        = time.Second
+)
+
+// Scheduler publishes scheduled articles when they are due. It keeps no state of
+// its own, every run asks the database for the next due time, so nothing is lost
+// when the process restarts.
+type Scheduler struct {
+	ArticleRepository domain.ArticleRepository
+	// MaxWait caps how long the scheduler sleeps between runs, so articles scheduled
+	// through another instance are still picked up.
+	MaxWait time.Duration
+
+	wake chan struct{}
+}
+
+func NewScheduler(repository domain.ArticleRepository, maxWait time.Duration) *Scheduler {
+	if maxWait <= 0 {
+		maxWait = defaultMaxWait
+	}
+	return &Scheduler{
+		ArticleRepository: repository,
+		MaxWait:           maxWait,
+		wake:              make(chan struct{}, 1),
+	}
+}
+
+// Wake makes a running scheduler recompute its next run without blocking the caller.
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes due articles until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(s.run(ctx))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// run publishes everything that is due and returns how long to sleep until the next article is.
+func (s *Scheduler) run(ctx context.Context) time.Duration {
+	published, err := s.ArticleRepository.PublishDue(ctx, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return s.MaxWait
+	}
+	if published > 0 {
+		logging.FromContext(ctx).Infof("published %d scheduled articles", published)
+	}
+
+	next, ok, err := s.ArticleRepository.NextScheduled(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return s.MaxWait
+	}
+	if !ok {
+		return s.MaxWait
+	}
+
+	wait := time.Until(next)
+	if wait < minWait {
+		wait = minWait
+	}
+	if wait > s.MaxWait {
+		wait = s.MaxWait
+	}
+	return wait
+}
@@ -2,60 +2,352 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
-	"github.com/sirupsen/logrus"
 	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/logging"
+	"go-boilerplate/markdown"
+	"go-boilerplate/slug"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+
+	// fallbackSlug is used for titles that have nothing to transliterate.
+	fallbackSlug = "article"
+	// maxSlugAttempts bounds the retries when a concurrent write takes the slug we picked.
+	maxSlugAttempts = 3
+
+	maxTags      = 10
+	maxTagLength = 50
+	// maxTagSlugLength is the size of tags.slug, transliterating can make a slug longer than its name.
+	maxTagSlugLength = 60
+
+	defaultPopularDays = 7
+	maxPopularDays     = 365
+)
+
+// reservedSlugs are taken by static routes under /article.
+var reservedSlugs = map[string]bool{
+	"search":  true,
+	"popular": true,
+}
+
 type articleUsecase struct {
 	ArticleRepository domain.ArticleRepository
-	ContextTimeout time.Duration
+	TagRepository     domain.TagRepository
+	Media             domain.MediaUsecase
+	Scheduler         domain.ArticleScheduler
+	Views             domain.ArticleViewCounter
+	Transactor        domain.Transactor
+	ContextTimeout    time.Duration
 }
 
 func (a articleUsecase) CreateArticle(ctx context.Context, article *domain.Article) error {
-	slug := strings.ReplaceAll(article.Title," ", "-")
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
 	article.ID = uuid.New()
-	article.Slug = slug
+	article.AuthorID = principal.UserID
+	article.UpdatedBy = principal.UserID
+	article.Status = domain.ArticleStatusDraft
+	article.Version = 1
+	if article.Language == "" {
+		article.Language = helper.SearchLanguage()
+	}
 
-	err := a.ArticleRepository.Create(ctx, article)
+	tags, err := normalizeTags(article.Tags)
 	if err != nil {
-		logrus.Warningln(err)
 		return err
 	}
 
-	return nil
+	if err := renderDescription(article); err != nil {
+		return err
+	}
+
+	if err := a.checkCover(ctx, article.CoverID); err != nil {
+		return err
+	}
+
+	// The article and its tags are saved together or not at all.
+	return a.Transactor.Transaction(ctx, func(ctx context.Context) error {
+		for attempt := 1; ; attempt++ {
+			article.Slug, err = a.uniqueSlug(ctx, article.Title, article.ID)
+			if err != nil {
+				return err
+			}
+
+			err = a.ArticleRepository.Create(ctx, article)
+			if !errors.Is(err, domain.ErrSlugTaken) || attempt == maxSlugAttempts {
+				break
+			}
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warnln(err)
+			return err
+		}
+
+		if len(tags) == 0 {
+			article.Tags = []domain.Tag{}
+			return nil
+		}
+		article.Tags, err = a.saveTags(ctx, article.ID, tags)
+		return err
+	})
 }
 
 func (a articleUsecase) UpdateArticle(ctx context.Context, id uuid.UUID, article *domain.Article) (res interface{}, err error) {
-	slug := strings.ReplaceAll(article.Title," ", "-")
-	article.Slug = slug
+	current, err := a.ArticleRepository.FindBy(ctx, "id", id.String())
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, err
+	}
+
+	if err := a.authorize(ctx, current, domain.PermissionArticlesUpdate); err != nil {
+		return nil, err
+	}
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	article.UpdatedBy = principal.UserID
+
+	tags, err := normalizeTags(article.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if article.Description != "" {
+		if err := renderDescription(article); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.checkCover(ctx, article.CoverID); err != nil {
+		return nil, err
+	}
+
+	updated := article
+	err = a.Transactor.Transaction(ctx, func(ctx context.Context) error {
+		for attempt := 1; ; attempt++ {
+			// Keep the slug, and any links to it, unless the title actually changed.
+			if article.Title != current.Title {
+				article.Slug, err = a.uniqueSlug(ctx, article.Title, id)
+				if err != nil {
+					return err
+				}
+			}
+
+			updated, err = a.ArticleRepository.Update(ctx, id, article)
+			if !errors.Is(err, domain.ErrSlugTaken) || attempt == maxSlugAttempts {
+				break
+			}
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warnln(err)
+			return err
+		}
 
-	article, err = a.ArticleRepository.Update(ctx, id, article)
+		if tags == nil {
+			return nil
+		}
+		updated.Tags, err = a.saveTags(ctx, id, tags)
+		return err
+	})
 	if err != nil {
-		logrus.Warnln(err)
 		return nil, err
 	}
 
-	return article, nil
+	if err := a.present(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+
+}
+
+func (a articleUsecase) DeleteArticle(ctx context.Context, id uuid.UUID, version int) error {
+	art, err := a.ArticleRepository.FindBy(ctx, "id", id.String())
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return err
+	}
+
+	if err := a.authorize(ctx, art, domain.PermissionArticlesDelete); err != nil {
+		return err
+	}
+
+	if version > 0 && version != art.Version {
+		return &domain.VersionConflictError{Current: art.Version}
+	}
+
+	err = a.ArticleRepository.Delete(ctx, id, version)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return err
+	}
+	return nil
+}
+
+func (a articleUsecase) RestoreArticle(ctx context.Context, id uuid.UUID) error {
+	art, err := a.ArticleRepository.FindWithTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.authorize(ctx, art, domain.PermissionArticlesDelete); err != nil {
+		return err
+	}
 
+	err = a.ArticleRepository.Restore(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return err
+	}
+	return nil
 }
 
-func (a articleUsecase) DeleteArticle(ctx context.Context, id uuid.UUID) error {
-	err := a.ArticleRepository.Delete(ctx, id)
+func (a articleUsecase) PurgeArticle(ctx context.Context, id uuid.UUID) error {
+	art, err := a.ArticleRepository.FindWithTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.authorize(ctx, art, domain.PermissionArticlesDelete); err != nil {
+		return err
+	}
+
+	err = a.ArticleRepository.ForceDelete(ctx, id)
 	if err != nil {
-		logrus.Warnln(err)
+		logging.FromContext(ctx).Warnln(err)
 		return err
 	}
 	return nil
 }
 
-func (a articleUsecase) GetArticleBySlug(ctx context.Context, slug string) (res interface{}, err error) {
+func (a articleUsecase) FetchArticles(ctx context.Context, filter *domain.ArticleFilter) (res interface{}, err error) {
+	if filter.Trashed != "" {
+		if err := a.authorizePermission(ctx, domain.PermissionArticlesDelete); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.Status == "" {
+		filter.Status = domain.ArticleStatusPublished
+	}
+	if filter.Status != domain.ArticleStatusPublished {
+		principal, err := helper.RequirePrincipal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if filter.AuthorID != principal.UserID && !principal.Can(domain.PermissionArticlesUpdate) {
+			return nil, domain.ErrForbidden
+		}
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+	if filter.Order == "" {
+		filter.Order = "desc"
+	}
+
+	var cursor *domain.ArticleCursor
+	if filter.Cursor != "" {
+		cursor, err = decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return nil, domain.ErrInvalidCursor
+		}
+		filter.Offset = 0
+	}
+
+	// Ask for one extra row to find out whether there is another page in the direction we read.
+	query := *filter
+	query.Limit = filter.Limit + 1
+
+	articles, total, err := a.ArticleRepository.Fetch(ctx, &query, cursor)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, err
+	}
+
+	backward := cursor != nil && cursor.Backward
+	hasMore := len(articles) > filter.Limit
+	if hasMore && backward {
+		articles = articles[1:]
+	} else if hasMore {
+		articles = articles[:filter.Limit]
+	}
+
+	page := &domain.ArticlePage{
+		Articles: articles,
+		Meta: domain.PageMeta{
+			Total:  total,
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+	}
+
+	if len(articles) > 0 {
+		if hasMore || backward {
+			page.Meta.NextCursor = cursorFor(articles[len(articles)-1], filter, false)
+		}
+		if (hasMore && backward) || (!backward && (cursor != nil || filter.Offset > 0)) {
+			page.Meta.PrevCursor = cursorFor(articles[0], filter, true)
+		}
+	}
+
+	if page.Articles == nil {
+		page.Articles = []domain.Article{}
+	}
+
+	loaded := make([]*domain.Article, len(page.Articles))
+	for i := range page.Articles {
+		loaded[i] = &page.Articles[i]
+	}
+	if err := a.present(ctx, loaded...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (a articleUsecase) GetArticleBySlug(ctx context.Context, slug string) (res *domain.Article, err error) {
 	art, err := a.ArticleRepository.FindBy(ctx, "slug", slug)
+	if errors.Is(err, domain.ErrNotFound) {
+		art, err = a.ArticleRepository.FindByOldSlug(ctx, slug)
+	}
 
 	if err != nil {
-		logrus.Warnln(err)
+		logging.FromContext(ctx).Warnln(err)
+		return nil, err
+	}
+
+	// Unpublished articles do not exist as far as the public is concerned.
+	if art.Status != domain.ArticleStatusPublished && a.authorize(ctx, art, domain.PermissionArticlesUpdate) != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	if err := a.present(ctx, art); err != nil {
 		return nil, err
 	}
 
@@ -63,9 +355,437 @@ func (a articleUsecase) GetArticleBySlug(ctx context.Context, slug string) (res
 
 }
 
-func NewArticleUsecase(repository domain.ArticleRepository, duration time.Duration) domain.ArticleUsecase{
+func (a articleUsecase) RecordView(ctx context.Context, article *domain.Article, visitor string) {
+	// Authors and editors previewing drafts do not count.
+	if a.Views == nil || article.Status != domain.ArticleStatusPublished {
+		return
+	}
+	a.Views.Record(article.ID, visitor)
+}
+
+func (a articleUsecase) PopularArticles(ctx context.Context, days, limit int) (res interface{}, err error) {
+	if days <= 0 {
+		days = defaultPopularDays
+	}
+	if days > maxPopularDays {
+		days = maxPopularDays
+	}
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	// Views are counted per UTC day, the window covers today and the days before it.
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1))
+	popular, err := a.ArticleRepository.Popular(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	if popular == nil {
+		popular = []domain.PopularArticle{}
+	}
+
+	loaded := make([]*domain.Article, len(popular))
+	for i := range popular {
+		loaded[i] = &popular[i].Article
+	}
+	if err := a.present(ctx, loaded...); err != nil {
+		return nil, err
+	}
+	return popular, nil
+}
+
+func (a articleUsecase) PublishArticle(ctx context.Context, id uuid.UUID, at time.Time) (res interface{}, err error) {
+	status := domain.ArticleStatusPublished
+	if at.IsZero() || !at.After(time.Now()) {
+		at = time.Now()
+	} else {
+		status = domain.ArticleStatusScheduled
+	}
+
+	res, err = a.changeStatus(ctx, id, status, pg.NullTime{Time: at})
+	if err != nil {
+		return nil, err
+	}
+
+	if status == domain.ArticleStatusScheduled && a.Scheduler != nil {
+		a.Scheduler.Wake()
+	}
+	return res, nil
+}
+
+func (a articleUsecase) UnpublishArticle(ctx context.Context, id uuid.UUID) (res interface{}, err error) {
+	return a.changeStatus(ctx, id, domain.ArticleStatusDraft, pg.NullTime{})
+}
+
+func (a articleUsecase) ArchiveArticle(ctx context.Context, id uuid.UUID) (res interface{}, err error) {
+	art, err := a.ArticleRepository.FindBy(ctx, "id", id.String())
+	if err != nil {
+		return nil, err
+	}
+	return a.changeStatus(ctx, id, domain.ArticleStatusArchived, art.PublishedAt)
+}
+
+func (a articleUsecase) FetchRevisions(ctx context.Context, id uuid.UUID) (res interface{}, err error) {
+	if _, err := a.editableArticle(ctx, id); err != nil {
+		return nil, err
+	}
+
+	revisions, err := a.ArticleRepository.FetchRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []domain.ArticleRevision{}
+	}
+	return revisions, nil
+}
+
+func (a articleUsecase) DiffRevisions(ctx context.Context, id uuid.UUID, from, to int) (res interface{}, err error) {
+	art, err := a.editableArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := a.ArticleRepository.FindRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	title, description := art.Title, art.Description
+	if to != 0 {
+		rev, err := a.ArticleRepository.FindRevision(ctx, id, to)
+		if err != nil {
+			return nil, err
+		}
+		title, description = rev.Title, rev.Description
+	}
+
+	titleDiff, err := helper.DiffLines(old.Title, title)
+	if err != nil {
+		return nil, err
+	}
+	descriptionDiff, err := helper.DiffLines(old.Description, description)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ArticleDiff{
+		From:        from,
+		To:          to,
+		Title:       titleDiff,
+		Description: descriptionDiff,
+	}, nil
+}
+
+func (a articleUsecase) RestoreRevision(ctx context.Context, id uuid.UUID, revision, version int) (res interface{}, err error) {
+	if _, err := a.editableArticle(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rev, err := a.ArticleRepository.FindRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.UpdateArticle(ctx, id, &domain.Article{
+		Title:       rev.Title,
+		Description: rev.Description,
+		Version:     version,
+		UpdatedAt:   time.Now(),
+	})
+}
+
+// editableArticle loads the article after checking the caller may edit it.
+func (a articleUsecase) editableArticle(ctx context.Context, id uuid.UUID) (*domain.Article, error) {
+	art, err := a.ArticleRepository.FindBy(ctx, "id", id.String())
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, err
+	}
+
+	if err := a.authorize(ctx, art, domain.PermissionArticlesUpdate); err != nil {
+		return nil, err
+	}
+	return art, nil
+}
+
+// changeStatus moves the article to status after checking the caller may edit it.
+func (a articleUsecase) changeStatus(ctx context.Context, id uuid.UUID, status string, publishedAt pg.NullTime) (res *domain.Article, err error) {
+	if _, err := a.editableArticle(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := a.ArticleRepository.SetStatus(ctx, id, status, publishedAt); err != nil {
+		return nil, err
+	}
+
+	art, err := a.ArticleRepository.FindBy(ctx, "id", id.String())
+	if err != nil {
+		return nil, err
+	}
+	return art, a.present(ctx, art)
+}
+
+// saveTags creates the tags that do not exist yet and makes them the tags of the article.
+func (a articleUsecase) saveTags(ctx context.Context, articleID uuid.UUID, tags []domain.Tag) ([]domain.Tag, error) {
+	saved, err := a.TagRepository.Ensure(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(saved))
+	for i, tag := range saved {
+		ids[i] = tag.ID
+	}
+	if err := a.TagRepository.SetArticleTags(ctx, articleID, ids); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// present gets loaded articles ready to be returned, it attaches their tags and covers
+// and renders descriptions saved before Markdown support.
+func (a articleUsecase) present(ctx context.Context, articles ...*domain.Article) error {
+	if err := a.attachTags(ctx, articles...); err != nil {
+		return err
+	}
+	if err := a.attachCovers(ctx, articles...); err != nil {
+		return err
+	}
+
+	for _, art := range articles {
+		if art.DescriptionHTML == "" && art.Description != "" {
+			if err := renderDescription(art); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attachCovers loads the cover images of all articles at once.
+func (a articleUsecase) attachCovers(ctx context.Context, articles ...*domain.Article) error {
+	ids := make([]uuid.UUID, 0, len(articles))
+	for _, art := range articles {
+		if art.CoverID != uuid.Nil {
+			ids = append(ids, art.CoverID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	covers, err := a.Media.FindMany(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, art := range articles {
+		art.Cover = covers[art.CoverID]
+	}
+	return nil
+}
+
+// checkCover makes sure a cover is an image the caller uploaded, or one they may use
+// because they can edit any article.
+func (a articleUsecase) checkCover(ctx context.Context, coverID uuid.UUID) error {
+	if coverID == uuid.Nil {
+		return nil
+	}
+
+	cover, err := a.Media.Find(ctx, coverID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidCover
+	}
+	if err != nil {
+		return err
+	}
+	if !cover.IsImage() {
+		return domain.ErrInvalidCover
+	}
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if cover.OwnerID != principal.UserID && !principal.Can(domain.PermissionArticlesUpdate) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// attachTags loads the tags of all articles with a single query, articles whose tags
+// were just saved keep them.
+func (a articleUsecase) attachTags(ctx context.Context, articles ...*domain.Article) error {
+	ids := make([]uuid.UUID, 0, len(articles))
+	for _, art := range articles {
+		if art.Tags == nil {
+			ids = append(ids, art.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tags, err := a.TagRepository.FetchByArticles(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, art := range articles {
+		if art.Tags != nil {
+			continue
+		}
+		art.Tags = tags[art.ID]
+		if art.Tags == nil {
+			art.Tags = []domain.Tag{}
+		}
+	}
+	return nil
+}
+
+// renderDescription fills in the HTML, excerpt and reading time of the Markdown description.
+func renderDescription(article *domain.Article) error {
+	doc, err := markdown.Render(article.Description)
+	if err != nil {
+		return err
+	}
+
+	article.DescriptionHTML = doc.HTML
+	article.Excerpt = doc.Excerpt
+	article.ReadingTime = doc.ReadingTime
+	return nil
+}
+
+// normalizeTags trims the tag names and drops those that share a slug. It keeps a nil
+// slice nil so callers can tell "no tags" from "leave the tags alone".
+func normalizeTags(tags []domain.Tag) ([]domain.Tag, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	res := make([]domain.Tag, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	now := time.Now()
+	for _, tag := range tags {
+		name := strings.Join(strings.Fields(tag.Name), " ")
+		tagSlug := slug.Make(name)
+		if tagSlug == "" || len(tagSlug) > maxTagSlugLength || utf8.RuneCountInString(name) > maxTagLength {
+			return nil, domain.ErrInvalidTag
+		}
+		if seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+
+		res = append(res, domain.Tag{ID: uuid.New(), Name: name, Slug: tagSlug, CreatedAt: now, UpdatedAt: now})
+	}
+
+	if len(res) > maxTags {
+		return nil, domain.ErrInvalidTag
+	}
+	return res, nil
+}
+
+func (a articleUsecase) SearchArticles(ctx context.Context, search *domain.ArticleSearch) (res interface{}, err error) {
+	if search.Limit <= 0 {
+		search.Limit = defaultPageSize
+	}
+	if search.Limit > maxPageSize {
+		search.Limit = maxPageSize
+	}
+	if search.Language == "" {
+		search.Language = helper.SearchLanguage()
+	}
+
+	results, total, err := a.ArticleRepository.Search(ctx, search)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, err
+	}
+
+	if results == nil {
+		results = []domain.ArticleSearchResult{}
+	}
+
+	loaded := make([]*domain.Article, len(results))
+	for i := range results {
+		loaded[i] = &results[i].Article
+	}
+	if err := a.present(ctx, loaded...); err != nil {
+		return nil, err
+	}
+
+	return &domain.ArticleSearchPage{
+		Articles: results,
+		Meta: domain.PageMeta{
+			Total:  total,
+			Limit:  search.Limit,
+			Offset: search.Offset,
+		},
+	}, nil
+}
+
+// uniqueSlug derives a slug from title, numbering it when another article has or had it.
+func (a articleUsecase) uniqueSlug(ctx context.Context, title string, articleID uuid.UUID) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = fallbackSlug
+	}
+
+	taken, err := a.ArticleRepository.TakenSlugs(ctx, base, articleID)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken)+len(reservedSlugs))
+	for s := range reservedSlugs {
+		used[s] = true
+	}
+	for _, s := range taken {
+		used[s] = true
+	}
+
+	candidate := base
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+	return candidate, nil
+}
+
+// authorize makes sure the caller is the author of art or has been granted permission.
+func (a articleUsecase) authorize(ctx context.Context, art *domain.Article, permission string) error {
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if art.AuthorID == principal.UserID {
+		return nil
+	}
+	return a.authorizePermission(ctx, permission)
+}
+
+func (a articleUsecase) authorizePermission(ctx context.Context, permission string) error {
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if !principal.Can(permission) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func NewArticleUsecase(repository domain.ArticleRepository, tagRepository domain.TagRepository, media domain.MediaUsecase, scheduler domain.ArticleScheduler, views domain.ArticleViewCounter, transactor domain.Transactor, duration time.Duration) domain.ArticleUsecase {
 	return &articleUsecase{
 		ArticleRepository: repository,
+		TagRepository:     tagRepository,
+		Media:             media,
+		Scheduler:         scheduler,
+		Views:             views,
+		Transactor:        transactor,
 		ContextTimeout:    duration,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,909 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go-boilerplate/article/repository/memory"
+	"go-boilerplate/article/usecase"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// tagRepository keeps tags in memory, it only implements what the article usecase uses.
+type tagRepository struct {
+	domain.TagRepository
+	mu       sync.Mutex
+	bySlug   map[string]domain.Tag
+	articles map[uuid.UUID][]uuid.UUID
+}
+
+func newTagRepository() *tagRepository {
+	return &tagRepository{bySlug: map[string]domain.Tag{}, articles: map[uuid.UUID][]uuid.UUID{}}
+}
+
+func (r *tagRepository) Ensure(ctx context.Context, tags []domain.Tag) ([]domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make([]domain.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if existing, ok := r.bySlug[tag.Slug]; ok {
+			tag = existing
+		}
+		r.bySlug[tag.Slug] = tag
+		res = append(res, tag)
+	}
+	return res, nil
+}
+
+func (r *tagRepository) SetArticleTags(ctx context.Context, articleID uuid.UUID, tagIDs []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.articles[articleID] = tagIDs
+	return nil
+}
+
+func (r *tagRepository) FetchByArticles(ctx context.Context, articleIDs []uuid.UUID) (map[uuid.UUID][]domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make(map[uuid.UUID][]domain.Tag)
+	for _, articleID := range articleIDs {
+		for _, tagID := range r.articles[articleID] {
+			for _, tag := range r.bySlug {
+				if tag.ID == tagID {
+					res[articleID] = append(res[articleID], tag)
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// mediaUsecase serves a fixed set of media, it only implements what the article usecase uses.
+type mediaUsecase struct {
+	domain.MediaUsecase
+	media map[uuid.UUID]*domain.Media
+}
+
+func (m mediaUsecase) Find(ctx context.Context, id uuid.UUID) (*domain.Media, error) {
+	if media, ok := m.media[id]; ok {
+		return media, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m mediaUsecase) FindMany(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Media, error) {
+	res := make(map[uuid.UUID]*domain.Media)
+	for _, id := range ids {
+		if media, ok := m.media[id]; ok {
+			res[id] = media
+		}
+	}
+	return res, nil
+}
+
+type scheduler struct {
+	mu    sync.Mutex
+	wakes int
+}
+
+func (s *scheduler) Wake() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wakes++
+}
+
+// transactor counts the transactions the usecase opens, the memory repositories need no rollback.
+type transactor struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (t *transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	t.count++
+	t.mu.Unlock()
+	return fn(ctx)
+}
+
+type viewCounter struct {
+	mu      sync.Mutex
+	visits  map[uuid.UUID]int
+	counted map[string]bool
+}
+
+func (v *viewCounter) Record(articleID uuid.UUID, visitor string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.counted[articleID.String()+visitor] {
+		return false
+	}
+	v.counted[articleID.String()+visitor] = true
+	v.visits[articleID]++
+	return true
+}
+
+var (
+	author = &domain.Principal{UserID: uuid.New(), TokenID: uuid.New()}
+	other  = &domain.Principal{UserID: uuid.New(), TokenID: uuid.New()}
+	editor = &domain.Principal{UserID: uuid.New(), TokenID: uuid.New(), Permissions: []string{
+		domain.PermissionArticlesUpdate, domain.PermissionArticlesDelete,
+	}}
+)
+
+func as(principal *domain.Principal) context.Context {
+	return helper.WithPrincipal(context.Background(), principal)
+}
+
+type fixture struct {
+	articles  domain.ArticleRepository
+	tags      *tagRepository
+	media     mediaUsecase
+	scheduler *scheduler
+	views     *viewCounter
+	tx        *transactor
+	usecase   domain.ArticleUsecase
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		articles:  memory.NewMemoryArticleRepository(),
+		tags:      newTagRepository(),
+		media:     mediaUsecase{media: map[uuid.UUID]*domain.Media{}},
+		scheduler: &scheduler{},
+		views:     &viewCounter{visits: map[uuid.UUID]int{}, counted: map[string]bool{}},
+		tx:        &transactor{},
+	}
+	f.usecase = usecase.NewArticleUsecase(f.articles, f.tags, f.media, f.scheduler, f.views, f.tx, time.Second)
+	return f
+}
+
+// create stores an article written by author, published when status says so.
+func (f *fixture) create(t *testing.T, title, description, status string) *domain.Article {
+	t.Helper()
+	art := &domain.Article{Title: title, Description: description, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := f.usecase.CreateArticle(as(author), art); err != nil {
+		t.Fatalf("create %q: %v", title, err)
+	}
+	if status != domain.ArticleStatusDraft {
+		if err := f.articles.SetStatus(context.Background(), art.ID, status, pg.NullTime{Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := f.articles.FindWithTrashed(context.Background(), art.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func (f *fixture) image(owner uuid.UUID, mimeType string) uuid.UUID {
+	id := uuid.New()
+	f.media.media[id] = &domain.Media{ID: id, OwnerID: owner, MimeType: mimeType}
+	return id
+}
+
+func TestCreateArticle(t *testing.T) {
+	f := newFixture()
+	f.create(t, "Hello World", "first", domain.ArticleStatusDraft)
+	cover := f.image(author.UserID, "image/png")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		article  domain.Article
+		wantErr  error
+		wantSlug string
+		wantTags int
+	}{
+		{name: "draft with a unique slug", ctx: as(author),
+			article: domain.Article{Title: "Another Post", Description: "**bold**"}, wantSlug: "another-post"},
+		{name: "numbered slug when taken", ctx: as(author),
+			article: domain.Article{Title: "Hello World", Description: "again"}, wantSlug: "hello-world-2"},
+		{name: "next free number", ctx: as(author),
+			article: domain.Article{Title: "Hello, World!", Description: "once more"}, wantSlug: "hello-world-3"},
+		{name: "title without a slug", ctx: as(author),
+			article: domain.Article{Title: "日本語", Description: "x"}, wantSlug: "article"},
+		{name: "reserved slug", ctx: as(author),
+			article: domain.Article{Title: "Search", Description: "x"}, wantSlug: "search-2"},
+		{name: "tags are deduplicated", ctx: as(author),
+			article:  domain.Article{Title: "Tagged", Description: "x", Tags: []domain.Tag{{Name: "Go"}, {Name: "go"}, {Name: "Web"}}},
+			wantSlug: "tagged", wantTags: 2},
+		{name: "own cover image", ctx: as(author),
+			article: domain.Article{Title: "Covered", Description: "x", CoverID: cover}, wantSlug: "covered"},
+		{name: "invalid tag", ctx: as(author),
+			article: domain.Article{Title: "Bad", Description: "x", Tags: []domain.Tag{{Name: "!!!"}}}, wantErr: domain.ErrInvalidTag},
+		{name: "unknown cover", ctx: as(author),
+			article: domain.Article{Title: "Bad", Description: "x", CoverID: uuid.New()}, wantErr: domain.ErrInvalidCover},
+		{name: "someone else's cover", ctx: as(other),
+			article: domain.Article{Title: "Bad", Description: "x", CoverID: cover}, wantErr: domain.ErrForbidden},
+		{name: "anonymous", ctx: context.Background(),
+			article: domain.Article{Title: "Bad", Description: "x"}, wantErr: domain.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			art := tt.article
+			err := f.usecase.CreateArticle(tt.ctx, &art)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if art.Slug != tt.wantSlug {
+				t.Errorf("slug = %q, want %q", art.Slug, tt.wantSlug)
+			}
+			if art.Status != domain.ArticleStatusDraft || art.Version != 1 {
+				t.Errorf("status %s at version %d, want a draft at version 1", art.Status, art.Version)
+			}
+			if art.DescriptionHTML == "" || art.Excerpt == "" {
+				t.Error("description was not rendered")
+			}
+			if len(art.Tags) != tt.wantTags {
+				t.Errorf("got %d tags, want %d", len(art.Tags), tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestCreateArticleNormalizesTags(t *testing.T) {
+	many, manySlugs := make([]string, 11), make([]string, 11)
+	for i := range many {
+		many[i], manySlugs[i] = "tag "+strconv.Itoa(i), "tag-"+strconv.Itoa(i)
+	}
+
+	tests := []struct {
+		name      string
+		tags      []string
+		wantNames []string
+		wantSlugs []string
+		wantErr   error
+	}{
+		{name: "spaces are collapsed", tags: []string{"  Web   Development "},
+			wantNames: []string{"Web Development"}, wantSlugs: []string{"web-development"}},
+		{name: "same slug kept once", tags: []string{"Go", "GO", "go!"},
+			wantNames: []string{"Go"}, wantSlugs: []string{"go"}},
+		{name: "transliterated", tags: []string{"Привет"},
+			wantNames: []string{"Привет"}, wantSlugs: []string{"privet"}},
+		{name: "longest name", tags: []string{strings.Repeat("a", 50)},
+			wantNames: []string{strings.Repeat("a", 50)}, wantSlugs: []string{strings.Repeat("a", 50)}},
+		{name: "name too long", tags: []string{strings.Repeat("a", 51)}, wantErr: domain.ErrInvalidTag},
+		{name: "slug too long", tags: []string{strings.Repeat("щ", 16)}, wantErr: domain.ErrInvalidTag},
+		{name: "no slug", tags: []string{"!!!"}, wantErr: domain.ErrInvalidTag},
+		{name: "blank", tags: []string{"   "}, wantErr: domain.ErrInvalidTag},
+		{name: "too many", tags: many, wantErr: domain.ErrInvalidTag},
+		{name: "duplicates do not count against the limit", tags: append(many[:10:10], "TAG 0"),
+			wantNames: many[:10], wantSlugs: manySlugs[:10]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			art := &domain.Article{Title: "Tagged", Description: "x"}
+			for _, name := range tt.tags {
+				art.Tags = append(art.Tags, domain.Tag{Name: name})
+			}
+
+			err := f.usecase.CreateArticle(as(author), art)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if len(art.Tags) != len(tt.wantNames) {
+				t.Fatalf("got %d tags, want %d", len(art.Tags), len(tt.wantNames))
+			}
+			for i, tag := range art.Tags {
+				if tag.Name != tt.wantNames[i] {
+					t.Errorf("tag %d is named %q, want %q", i, tag.Name, tt.wantNames[i])
+				}
+				if tag.Slug != tt.wantSlugs[i] {
+					t.Errorf("tag %d has slug %q, want %q", i, tag.Slug, tt.wantSlugs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateArticleSavesTagsInItsTransaction(t *testing.T) {
+	f := newFixture()
+	art := &domain.Article{Title: "Tagged", Description: "x", Tags: []domain.Tag{{Name: "Go"}}}
+	if err := f.usecase.CreateArticle(as(author), art); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.tx.count != 1 {
+		t.Errorf("opened %d transactions, want 1", f.tx.count)
+	}
+	if ids := f.tags.articles[art.ID]; len(ids) != 1 {
+		t.Errorf("article has %d stored tags, want 1", len(ids))
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		article  domain.Article
+		wantErr  error
+		wantSlug string
+	}{
+		{name: "author renames", ctx: as(author),
+			article: domain.Article{Title: "Renamed", Description: "new"}, wantSlug: "renamed"},
+		{name: "same title keeps the slug", ctx: as(author),
+			article: domain.Article{Title: "Original", Description: "new"}, wantSlug: "original"},
+		{name: "editor edits", ctx: as(editor),
+			article: domain.Article{Title: "Edited", Description: "new"}, wantSlug: "edited"},
+		{name: "expected version matches", ctx: as(author),
+			article: domain.Article{Title: "Original", Description: "new", Version: 1}, wantSlug: "original"},
+		{name: "stale version", ctx: as(author),
+			article: domain.Article{Title: "Original", Description: "new", Version: 7}, wantErr: domain.ErrVersionConflict},
+		{name: "someone else", ctx: as(other),
+			article: domain.Article{Title: "Mine", Description: "new"}, wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			original := f.create(t, "Original", "old", domain.ArticleStatusDraft)
+
+			art := tt.article
+			res, err := f.usecase.UpdateArticle(tt.ctx, original.ID, &art)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			updated := res.(*domain.Article)
+			if updated.Version != 2 {
+				t.Errorf("version = %d, want 2", updated.Version)
+			}
+
+			stored, _ := f.articles.FindWithTrashed(context.Background(), original.ID)
+			if stored.Slug != tt.wantSlug || stored.Description != "new" {
+				t.Errorf("stored %q with %q, want %q with %q", stored.Slug, stored.Description, tt.wantSlug, "new")
+			}
+			if tt.wantSlug != original.Slug {
+				if moved, err := f.usecase.GetArticleBySlug(as(author), original.Slug); err != nil || moved.ID != original.ID {
+					t.Errorf("old slug does not lead to the article, err = %v", err)
+				}
+			}
+		})
+	}
+
+	t.Run("missing article", func(t *testing.T) {
+		f := newFixture()
+		_, err := f.usecase.UpdateArticle(as(author), uuid.New(), &domain.Article{Title: "x", Description: "x"})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("err = %v, want %v", err, domain.ErrNotFound)
+		}
+	})
+}
+
+func TestUpdateArticleWithTagsPresentsTheCover(t *testing.T) {
+	f := newFixture()
+	original := f.create(t, "Original", "old", domain.ArticleStatusDraft)
+	cover := f.image(author.UserID, "image/png")
+
+	res, err := f.usecase.UpdateArticle(as(author), original.ID, &domain.Article{Title: "Original", Description: "new",
+		CoverID: cover, Tags: []domain.Tag{{Name: "Go"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := res.(*domain.Article)
+	if updated.Cover == nil || updated.Cover.ID != cover {
+		t.Errorf("cover = %+v, want %s", updated.Cover, cover)
+	}
+	if len(updated.Tags) != 1 || updated.Tags[0].Slug != "go" {
+		t.Errorf("tags = %+v, want the saved go tag", updated.Tags)
+	}
+}
+
+func TestDeleteArticle(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		version int
+		wantErr error
+	}{
+		{name: "author", ctx: as(author)},
+		{name: "editor", ctx: as(editor)},
+		{name: "current version", ctx: as(author), version: 1},
+		{name: "stale version", ctx: as(author), version: 3, wantErr: domain.ErrVersionConflict},
+		{name: "someone else", ctx: as(other), wantErr: domain.ErrForbidden},
+		{name: "anonymous", ctx: context.Background(), wantErr: domain.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			art := f.create(t, "Doomed", "x", domain.ArticleStatusDraft)
+
+			err := f.usecase.DeleteArticle(tt.ctx, art.ID, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = f.articles.FindBy(context.Background(), "id", art.ID.String())
+			if deleted := errors.Is(err, domain.ErrNotFound); deleted != (tt.wantErr == nil) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestRestoreAndPurgeArticle(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		trashed bool
+		purge   bool
+		wantErr error
+	}{
+		{name: "restore by author", ctx: as(author), trashed: true},
+		{name: "restore by editor", ctx: as(editor), trashed: true},
+		{name: "restore by someone else", ctx: as(other), trashed: true, wantErr: domain.ErrForbidden},
+		{name: "restore a live article", ctx: as(author), wantErr: domain.ErrNotFound},
+		{name: "purge by author", ctx: as(author), trashed: true, purge: true},
+		{name: "purge by someone else", ctx: as(other), trashed: true, purge: true, wantErr: domain.ErrForbidden},
+		{name: "purge a live article", ctx: as(author), purge: true, wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			art := f.create(t, "Trash", "x", domain.ArticleStatusDraft)
+			if tt.trashed {
+				if err := f.articles.Delete(context.Background(), art.ID, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			action := f.usecase.RestoreArticle
+			if tt.purge {
+				action = f.usecase.PurgeArticle
+			}
+			if err := action(tt.ctx, art.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			_, err := f.articles.FindWithTrashed(context.Background(), art.ID)
+			if gone := errors.Is(err, domain.ErrNotFound); gone != tt.purge {
+				t.Errorf("gone = %v, want %v", gone, tt.purge)
+			}
+		})
+	}
+}
+
+func TestFetchArticles(t *testing.T) {
+	f := newFixture()
+	for _, title := range []string{"Alpha", "Bravo", "Charlie"} {
+		f.create(t, title, "x", domain.ArticleStatusPublished)
+		time.Sleep(time.Millisecond)
+	}
+	f.create(t, "Draft", "x", domain.ArticleStatusDraft)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		filter  domain.ArticleFilter
+		want    []string
+		wantErr error
+	}{
+		{name: "published, newest first", ctx: context.Background(),
+			filter: domain.ArticleFilter{}, want: []string{"Charlie", "Bravo", "Alpha"}},
+		{name: "by title", ctx: context.Background(),
+			filter: domain.ArticleFilter{Sort: "title", Order: "asc", Limit: 2}, want: []string{"Alpha", "Bravo"}},
+		{name: "with offset", ctx: context.Background(),
+			filter: domain.ArticleFilter{Sort: "title", Order: "asc", Limit: 2, Offset: 2}, want: []string{"Charlie"}},
+		{name: "title search", ctx: context.Background(),
+			filter: domain.ArticleFilter{Title: "rav"}, want: []string{"Bravo"}},
+		{name: "own drafts", ctx: as(author),
+			filter: domain.ArticleFilter{Status: domain.ArticleStatusDraft, AuthorID: author.UserID}, want: []string{"Draft"}},
+		{name: "someone else's drafts", ctx: as(other),
+			filter: domain.ArticleFilter{Status: domain.ArticleStatusDraft, AuthorID: author.UserID}, wantErr: domain.ErrForbidden},
+		{name: "trash without permission", ctx: as(author),
+			filter: domain.ArticleFilter{Trashed: "only"}, wantErr: domain.ErrForbidden},
+		{name: "malformed cursor", ctx: context.Background(),
+			filter: domain.ArticleFilter{Cursor: "%%%"}, wantErr: domain.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			res, err := f.usecase.FetchArticles(tt.ctx, &filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			assertTitles(t, res.(*domain.ArticlePage).Articles, tt.want)
+		})
+	}
+
+	t.Run("cursor pages", func(t *testing.T) {
+		filter := domain.ArticleFilter{Sort: "title", Order: "asc", Limit: 1}
+		var titles []string
+		for {
+			res, err := f.usecase.FetchArticles(context.Background(), &filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page := res.(*domain.ArticlePage)
+			for _, art := range page.Articles {
+				titles = append(titles, art.Title)
+			}
+			if page.Meta.NextCursor == "" {
+				break
+			}
+			filter = domain.ArticleFilter{Sort: "title", Order: "asc", Limit: 1, Cursor: page.Meta.NextCursor}
+		}
+		if len(titles) != 3 || titles[0] != "Alpha" || titles[2] != "Charlie" {
+			t.Errorf("paged through %v", titles)
+		}
+	})
+}
+
+func TestGetArticleBySlug(t *testing.T) {
+	f := newFixture()
+	published := f.create(t, "Published", "x", domain.ArticleStatusPublished)
+	f.create(t, "Draft", "x", domain.ArticleStatusDraft)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		slug    string
+		want    uuid.UUID
+		wantErr error
+	}{
+		{name: "published", ctx: context.Background(), slug: "published", want: published.ID},
+		{name: "draft for the public", ctx: context.Background(), slug: "draft", wantErr: domain.ErrNotFound},
+		{name: "draft for someone else", ctx: as(other), slug: "draft", wantErr: domain.ErrNotFound},
+		{name: "unknown slug", ctx: context.Background(), slug: "nothing", wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.GetArticleBySlug(tt.ctx, tt.slug)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.ID != tt.want {
+				t.Errorf("got article %v, want %v", res.ID, tt.want)
+			}
+		})
+	}
+
+	t.Run("draft for the author", func(t *testing.T) {
+		res, err := f.usecase.GetArticleBySlug(as(author), "draft")
+		if err != nil || res.Status != domain.ArticleStatusDraft {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestSearchArticles(t *testing.T) {
+	f := newFixture()
+	f.create(t, "Go concurrency", "Channels and goroutines in Go", domain.ArticleStatusPublished)
+	f.create(t, "Cooking", "Pasta recipes", domain.ArticleStatusPublished)
+	f.create(t, "Go drafts", "Not yet public", domain.ArticleStatusDraft)
+
+	tests := []struct {
+		name    string
+		search  domain.ArticleSearch
+		want    []string
+		wantErr error
+	}{
+		{name: "match", search: domain.ArticleSearch{Query: "goroutines"}, want: []string{"Go concurrency"}},
+		{name: "drafts are left out", search: domain.ArticleSearch{Query: "go"}, want: []string{"Go concurrency"}},
+		{name: "no match", search: domain.ArticleSearch{Query: "rust"}, want: []string{}},
+		{name: "unknown language", search: domain.ArticleSearch{Query: "go", Language: "klingon"}, wantErr: domain.ErrUnknownLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := tt.search
+			res, err := f.usecase.SearchArticles(context.Background(), &search)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			page := res.(*domain.ArticleSearchPage)
+			articles := make([]domain.Article, len(page.Articles))
+			for i, result := range page.Articles {
+				articles[i] = result.Article
+			}
+			assertTitles(t, articles, tt.want)
+			if page.Meta.Total != len(tt.want) {
+				t.Errorf("total = %d, want %d", page.Meta.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		action     func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error)
+		wantStatus string
+		wantWakes  int
+		wantErr    error
+	}{
+		{name: "publish now", ctx: as(author), wantStatus: domain.ArticleStatusPublished,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.PublishArticle(ctx, id, time.Time{})
+			}},
+		{name: "schedule", ctx: as(author), wantStatus: domain.ArticleStatusScheduled, wantWakes: 1,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.PublishArticle(ctx, id, later)
+			}},
+		{name: "unpublish", ctx: as(editor), wantStatus: domain.ArticleStatusDraft,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.UnpublishArticle(ctx, id)
+			}},
+		{name: "archive", ctx: as(author), wantStatus: domain.ArticleStatusArchived,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.ArchiveArticle(ctx, id)
+			}},
+		{name: "publish someone else's", ctx: as(other), wantErr: domain.ErrForbidden,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.PublishArticle(ctx, id, time.Time{})
+			}},
+		{name: "archive anonymously", ctx: context.Background(), wantErr: domain.ErrUnauthenticated,
+			action: func(u domain.ArticleUsecase, ctx context.Context, id uuid.UUID) (interface{}, error) {
+				return u.ArchiveArticle(ctx, id)
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			art := f.create(t, "Status", "x", domain.ArticleStatusPublished)
+
+			res, err := tt.action(f.usecase, tt.ctx, art.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			changed := res.(*domain.Article)
+			if changed.Status != tt.wantStatus || changed.Version != art.Version+1 {
+				t.Errorf("got %s at version %d, want %s at version %d", changed.Status, changed.Version, tt.wantStatus, art.Version+1)
+			}
+			if f.scheduler.wakes != tt.wantWakes {
+				t.Errorf("scheduler woken %d times, want %d", f.scheduler.wakes, tt.wantWakes)
+			}
+		})
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	f := newFixture()
+	art := f.create(t, "First", "one", domain.ArticleStatusDraft)
+	for _, title := range []string{"Second", "Third"} {
+		if _, err := f.usecase.UpdateArticle(as(author), art.ID, &domain.Article{Title: title, Description: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("fetch", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			ctx     context.Context
+			want    []int
+			wantErr error
+		}{
+			{name: "author", ctx: as(author), want: []int{2, 1}},
+			{name: "someone else", ctx: as(other), wantErr: domain.ErrForbidden},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := f.usecase.FetchRevisions(tt.ctx, art.ID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+
+				revisions := res.([]domain.ArticleRevision)
+				if len(revisions) != len(tt.want) {
+					t.Fatalf("got %d revisions, want %d", len(revisions), len(tt.want))
+				}
+				for i, rev := range revisions {
+					if rev.Revision != tt.want[i] {
+						t.Errorf("revisions[%d] = %d, want %d", i, rev.Revision, tt.want[i])
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			from, to  int
+			wantTitle string
+			wantErr   error
+		}{
+			{name: "against the current content", from: 1, wantTitle: "Third"},
+			{name: "between revisions", from: 1, to: 2, wantTitle: "Second"},
+			{name: "unknown revision", from: 9, wantErr: domain.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := f.usecase.DiffRevisions(as(author), art.ID, tt.from, tt.to)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+
+				diff := res.(*domain.ArticleDiff)
+				last := diff.Title[len(diff.Title)-1]
+				if last.Op != domain.DiffInsert || last.Text != tt.wantTitle {
+					t.Errorf("title diff ends with %+v, want %s inserted", last, tt.wantTitle)
+				}
+			})
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			ctx       context.Context
+			revision  int
+			version   int
+			wantTitle string
+			wantErr   error
+		}{
+			{name: "stale version", ctx: as(author), revision: 1, version: 99, wantErr: domain.ErrVersionConflict},
+			{name: "author", ctx: as(author), revision: 1, wantTitle: "First"},
+			{name: "someone else", ctx: as(other), revision: 1, wantErr: domain.ErrForbidden},
+			{name: "unknown revision", ctx: as(author), revision: 42, wantErr: domain.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res, err := f.usecase.RestoreRevision(tt.ctx, art.ID, tt.revision, tt.version)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == nil && res.(*domain.Article).Title != tt.wantTitle {
+					t.Errorf("title = %q, want %q", res.(*domain.Article).Title, tt.wantTitle)
+				}
+			})
+		}
+	})
+}
+
+func TestRecordView(t *testing.T) {
+	f := newFixture()
+	published := f.create(t, "Published", "x", domain.ArticleStatusPublished)
+	draft := f.create(t, "Draft", "x", domain.ArticleStatusDraft)
+
+	tests := []struct {
+		name    string
+		article *domain.Article
+		visitor string
+		want    int
+	}{
+		{name: "first view", article: published, visitor: "a", want: 1},
+		{name: "repeated view", article: published, visitor: "a", want: 1},
+		{name: "another visitor", article: published, visitor: "b", want: 2},
+		{name: "draft preview", article: draft, visitor: "a", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.usecase.RecordView(context.Background(), tt.article, tt.visitor)
+			if got := f.views.visits[tt.article.ID]; got != tt.want {
+				t.Errorf("counted %d views, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopularArticles(t *testing.T) {
+	f := newFixture()
+	today := time.Now().UTC()
+	hot := f.create(t, "Hot", "x", domain.ArticleStatusPublished)
+	warm := f.create(t, "Warm", "x", domain.ArticleStatusPublished)
+	old := f.create(t, "Old", "x", domain.ArticleStatusPublished)
+	draft := f.create(t, "Draft", "x", domain.ArticleStatusDraft)
+
+	err := f.articles.AddViews(context.Background(), []domain.ArticleViews{
+		{ArticleID: hot.ID, Day: today, Views: 30},
+		{ArticleID: warm.ID, Day: today.AddDate(0, 0, -2), Views: 20},
+		{ArticleID: old.ID, Day: today.AddDate(0, 0, -20), Views: 100},
+		{ArticleID: draft.ID, Day: today, Views: 500},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		days  int
+		limit int
+		want  []string
+	}{
+		{name: "default window", want: []string{"Hot", "Warm"}},
+		{name: "today only", days: 1, want: []string{"Hot"}},
+		{name: "month", days: 30, want: []string{"Old", "Hot", "Warm"}},
+		{name: "limited", days: 30, limit: 1, want: []string{"Old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.PopularArticles(context.Background(), tt.days, tt.limit)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			popular := res.([]domain.PopularArticle)
+			articles := make([]domain.Article, len(popular))
+			for i, p := range popular {
+				articles[i] = p.Article
+			}
+			assertTitles(t, articles, tt.want)
+		})
+	}
+}
+
+func assertTitles(t *testing.T, articles []domain.Article, want []string) {
+	t.Helper()
+	if len(articles) != len(want) {
+		titles := make([]string, len(articles))
+		for i, art := range articles {
+			titles[i] = art.Title
+		}
+		t.Fatalf("got %v, want %v", titles, want)
+	}
+	for i, art := range articles {
+		if art.Title != want[i] {
+			t.Errorf("articles[%d] = %q, want %q", i, art.Title, want[i])
+		}
+	}
+}
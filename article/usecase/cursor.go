@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"go-boilerplate/domain"
+	"time"
+)
+
+func encodeCursor(cursor *domain.ArticleCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*domain.ArticleCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	cursor := new(domain.ArticleCursor)
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// cursorFor builds the cursor pointing at art for the sort order in filter.
+func cursorFor(art domain.Article, filter *domain.ArticleFilter, backward bool) string {
+	cursor := &domain.ArticleCursor{
+		Sort:     filter.Sort,
+		Order:    filter.Order,
+		ID:       art.ID,
+		Backward: backward,
+	}
+
+	switch filter.Sort {
+	case "updated_at":
+		cursor.Value = art.UpdatedAt.Format(time.RFC3339Nano)
+	case "title":
+		cursor.Value = art.Title
+	default:
+		cursor.Value = art.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return encodeCursor(cursor)
+}
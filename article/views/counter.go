@@ -0,0 +1,156 @@
+package views
+
+import (
+	"container/list"
+	"context"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWindow        = 30 * time.Minute
+	defaultFlushInterval = 10 * time.Second
+	defaultMaxVisitors   = 100000
+	// flushTimeout bounds the last flush on shutdown.
+	flushTimeout = 10 * time.Second
+)
+
+type (
+	seenKey struct {
+		articleID uuid.UUID
+		visitor   string
+	}
+
+	seenEntry struct {
+		key   seenKey
+		until time.Time
+	}
+
+	dayKey struct {
+		articleID uuid.UUID
+		day       string
+	}
+)
+
+// Counter counts article views in memory and flushes them to the database every
+// FlushInterval, so reading an article never waits for a write. Views counted since
+// the last flush are lost if the process dies without shutting down.
+type Counter struct {
+	ArticleRepository domain.ArticleRepository
+	// Window is how long a visitor's repeated reads of an article count as one.
+	Window        time.Duration
+	FlushInterval time.Duration
+	// MaxVisitors bounds the visitors remembered for Window. Past it the oldest are
+	// forgotten early, so their next read counts again.
+	MaxVisitors int
+
+	mu   sync.Mutex
+	seen map[seenKey]*list.Element
+	// order holds the seenEntry values oldest first, which is also the order they expire in.
+	order   *list.List
+	pending map[dayKey]int64
+	now     func() time.Time
+}
+
+func NewCounter(repository domain.ArticleRepository, window, flushInterval time.Duration) *Counter {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	return &Counter{
+		ArticleRepository: repository,
+		Window:            window,
+		FlushInterval:     flushInterval,
+		MaxVisitors:       defaultMaxVisitors,
+		seen:              make(map[seenKey]*list.Element),
+		order:             list.New(),
+		pending:           make(map[dayKey]int64),
+		now:               time.Now,
+	}
+}
+
+// Record counts a view of the article by visitor, unless the same visitor was already
+// counted within Window. It reports whether the view was counted.
+func (c *Counter) Record(articleID uuid.UUID, visitor string) bool {
+	now := c.now()
+	key := seenKey{articleID: articleID, visitor: visitor}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.forget(now)
+	if _, ok := c.seen[key]; ok {
+		return false
+	}
+	for c.order.Len() >= c.MaxVisitors && c.order.Len() > 0 {
+		c.remove(c.order.Front())
+	}
+	c.seen[key] = c.order.PushBack(seenEntry{key: key, until: now.Add(c.Window)})
+	c.pending[dayKey{articleID: articleID, day: now.UTC().Format("2006-01-02")}]++
+	return true
+}
+
+// forget drops the visitors whose window is over, c.mu must be held.
+func (c *Counter) forget(now time.Time) {
+	for front := c.order.Front(); front != nil && !now.Before(front.Value.(seenEntry).until); front = c.order.Front() {
+		c.remove(front)
+	}
+}
+
+func (c *Counter) remove(element *list.Element) {
+	delete(c.seen, c.order.Remove(element).(seenEntry).key)
+}
+
+// Run flushes the buffered views every FlushInterval until ctx is cancelled, then
+// flushes one last time before returning.
+func (c *Counter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			c.Flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			c.Flush(ctx)
+		}
+	}
+}
+
+// Flush writes the views counted so far. When the write fails they are kept for the next try.
+func (c *Counter) Flush(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[dayKey]int64)
+
+	c.forget(c.now())
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	batch := make([]domain.ArticleViews, 0, len(pending))
+	for key, views := range pending {
+		day, _ := time.Parse("2006-01-02", key.day)
+		batch = append(batch, domain.ArticleViews{ArticleID: key.articleID, Day: day, Views: views})
+	}
+
+	if err := c.ArticleRepository.AddViews(ctx, batch); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+
+		c.mu.Lock()
+		for key, views := range pending {
+			c.pending[key] += views
+		}
+		c.mu.Unlock()
+	}
+}
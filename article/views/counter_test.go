@@ -0,0 +1,48 @@
+package views
+
+import (
+	"github.com/google/uuid"
+	"go-boilerplate/article/repository/memory"
+	"testing"
+	"time"
+)
+
+func TestCounterRecord(t *testing.T) {
+	clock := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCounter(memory.NewMemoryArticleRepository(), 30*time.Minute, time.Hour)
+	c.MaxVisitors = 2
+	c.now = func() time.Time { return clock }
+
+	first, second := uuid.New(), uuid.New()
+	tests := []struct {
+		name    string
+		after   time.Duration
+		article uuid.UUID
+		visitor string
+		want    bool
+	}{
+		{name: "first read", article: first, visitor: "a", want: true},
+		{name: "read again", after: time.Minute, article: first, visitor: "a", want: false},
+		{name: "other visitor", article: first, visitor: "b", want: true},
+		{name: "other article", article: second, visitor: "a", want: true},
+		{name: "forgotten to stay within bounds", article: first, visitor: "a", want: true},
+		{name: "still remembered", article: second, visitor: "a", want: false},
+		{name: "window over", after: 30 * time.Minute, article: second, visitor: "a", want: true},
+	}
+
+	for _, tt := range tests {
+		clock = clock.Add(tt.after)
+		if got := c.Record(tt.article, tt.visitor); got != tt.want {
+			t.Errorf("%s: Record() = %v, want %v", tt.name, got, tt.want)
+		}
+		if len(c.seen) > c.MaxVisitors || len(c.seen) != c.order.Len() {
+			t.Fatalf("%s: remembering %d visitors in %d entries, want at most %d", tt.name, len(c.seen), c.order.Len(), c.MaxVisitors)
+		}
+	}
+
+	clock = clock.Add(time.Hour)
+	c.forget(clock)
+	if len(c.seen) != 0 || c.order.Len() != 0 {
+		t.Errorf("%d visitors remembered after their window, want none", len(c.seen))
+	}
+}
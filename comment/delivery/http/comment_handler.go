@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thedevsaddam/govalidator"
+	"go-boilerplate/domain"
+	"go-boilerplate/middleware"
+	"net/http"
+	"strconv"
+)
+
+type commentHandler struct {
+	commentUsecase domain.CommentUsecase
+}
+
+func NewCommentHandler(e *echo.Echo, usecase domain.CommentUsecase, customMiddleware *middleware.Middleware) {
+	handler := &commentHandler{commentUsecase: usecase}
+
+	e.GET("/article/:id/comments", handler.FetchCommentHandler, customMiddleware.OptionalAuth)
+	e.POST("/article/:id/comments", handler.StoreCommentHandler, customMiddleware.Auth, customMiddleware.RequireVerified)
+
+	comment := e.Group("/comment", customMiddleware.Auth)
+	comment.PUT("/:id", handler.UpdateCommentHandler, customMiddleware.RequireVerified)
+	comment.DELETE("/:id", handler.DestroyCommentHandler)
+	comment.POST("/:id/hide", handler.HideCommentHandler)
+	comment.POST("/:id/unhide", handler.UnhideCommentHandler)
+}
+
+func (c commentHandler) FetchCommentHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"limit":  []string{"numeric_between:1,50"},
+		"offset": []string{"numeric"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleID, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	offset, _ := strconv.Atoi(e.QueryParam("offset"))
+
+	res, err := c.commentUsecase.FetchComments(ctx, articleID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (c commentHandler) StoreCommentHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"body":      []string{"required", "max:5000"},
+		"parent_id": []string{"uuid"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	articleID, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	comment := domain.Comment{
+		ArticleID: articleID,
+		Body:      e.FormValue("body"),
+	}
+	comment.ParentID, _ = uuid.Parse(e.FormValue("parent_id"))
+
+	if err := c.commentUsecase.StoreComment(ctx, &comment); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusCreated, map[string]interface{}{
+		"status": "success",
+		"data":   comment,
+	})
+}
+
+func (c commentHandler) UpdateCommentHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"body": []string{"required", "max:5000"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := c.commentUsecase.UpdateComment(ctx, id, e.FormValue("body"))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (c commentHandler) DestroyCommentHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	if err := c.commentUsecase.DeleteComment(ctx, id); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (c commentHandler) HideCommentHandler(e echo.Context) error {
+	return c.moderate(e, c.commentUsecase.HideComment)
+}
+
+func (c commentHandler) UnhideCommentHandler(e echo.Context) error {
+	return c.moderate(e, c.commentUsecase.UnhideComment)
+}
+
+// moderate runs a hide or unhide on the comment named in the path.
+func (c commentHandler) moderate(e echo.Context, action func(context.Context, uuid.UUID) (interface{}, error)) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := action(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	database "go-boilerplate/db/postgresql"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+	"time"
+)
+
+type psqlCommentRepository struct {
+	DB *pg.DB
+}
+
+func NewPsqlCommentRepository(db *pg.DB) domain.CommentRepository {
+	return &psqlCommentRepository{DB: db}
+}
+
+func (c *psqlCommentRepository) Create(ctx context.Context, comment *domain.Comment) error {
+	if _, err := c.DB.ModelContext(ctx, comment).Insert(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	return nil
+}
+
+func (c *psqlCommentRepository) Find(ctx context.Context, id uuid.UUID) (comment *domain.Comment, err error) {
+	comment = new(domain.Comment)
+	if err := c.DB.ModelContext(ctx, comment).Where("id = ?", id).First(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return comment, nil
+}
+
+func (c *psqlCommentRepository) UpdateBody(ctx context.Context, id uuid.UUID, body string) error {
+	res, err := c.DB.ModelContext(ctx, (*domain.Comment)(nil)).
+		Set("body = ?", body).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (c *psqlCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := c.DB.ModelContext(ctx, (*domain.Comment)(nil)).Where("id = ?", id).Delete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (c *psqlCommentRepository) SetHidden(ctx context.Context, id uuid.UUID, hidden bool, moderatorID uuid.UUID) error {
+	query := c.DB.ModelContext(ctx, (*domain.Comment)(nil)).Where("id = ?", id)
+	if hidden {
+		query.Set("hidden_at = ?", time.Now()).Set("hidden_by = ?", moderatorID)
+	} else {
+		query.Set("hidden_at = NULL").Set("hidden_by = NULL")
+	}
+
+	res, err := query.Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (c *psqlCommentRepository) FetchThreads(ctx context.Context, articleID uuid.UUID, limit, offset int) (res []domain.Comment, total int, err error) {
+	// A deleted top level comment only stays around while someone's reply hangs below it.
+	query := c.DB.ModelContext(ctx, &res).
+		AllWithDeleted().
+		Where("article_id = ?", articleID).
+		Where("parent_id IS NULL").
+		Where(`deleted_at IS NULL OR EXISTS (
+			SELECT 1 FROM comments AS reply WHERE reply.root_id = comment.id AND reply.deleted_at IS NULL)`)
+
+	total, err = query.Count()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, database.TranslateError(err, nil)
+	}
+
+	err = query.Order("created_at DESC", "id DESC").Limit(limit).Offset(offset).Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, 0, database.TranslateError(err, nil)
+	}
+	return res, total, nil
+}
+
+func (c *psqlCommentRepository) FetchReplies(ctx context.Context, rootIDs []uuid.UUID, limit int) (res []domain.Comment, err error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	// Replies are younger than their parents, so the oldest ones of a thread never miss a parent.
+	err = c.DB.ModelContext(ctx, &res).
+		AllWithDeleted().
+		Where("root_id IN (?)", pg.In(rootIDs)).
+		Where(`id IN (SELECT id FROM (
+			SELECT id, row_number() OVER (PARTITION BY root_id ORDER BY created_at, id) AS number
+			FROM comments WHERE root_id IN (?)) AS numbered WHERE number <= ?)`, pg.In(rootIDs), limit).
+		Order("created_at ASC", "id ASC").
+		Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return res, nil
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"time"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+type commentUsecase struct {
+	CommentRepo    domain.CommentRepository
+	ArticleRepo    domain.ArticleRepository
+	ContextTimeout time.Duration
+}
+
+func (c *commentUsecase) FetchComments(ctx context.Context, articleID uuid.UUID, limit, offset int) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.ContextTimeout)
+	defer cancel()
+
+	article, err := c.ArticleRepo.FindBy(ctx, "id", articleID.String())
+	if err != nil {
+		return nil, err
+	}
+	if article.Status != domain.ArticleStatusPublished && !c.canEdit(ctx, article) {
+		return nil, domain.ErrNotFound
+	}
+
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	roots, total, err := c.CommentRepo.FetchThreads(ctx, articleID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	rootIDs := make([]uuid.UUID, len(roots))
+	for i, root := range roots {
+		rootIDs[i] = root.ID
+	}
+	replies, err := c.CommentRepo.FetchReplies(ctx, rootIDs, helper.CommentMaxReplies())
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]domain.Comment, len(replies))
+	for _, reply := range replies {
+		children[reply.ParentID] = append(children[reply.ParentID], reply)
+	}
+
+	viewer := c.viewer(ctx, article)
+	threads := make([]domain.Comment, 0, len(roots))
+	for _, root := range roots {
+		thread, _ := buildThread(root, children, viewer)
+		threads = append(threads, thread)
+	}
+
+	return &domain.CommentPage{
+		Threads: threads,
+		Meta: domain.PageMeta{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+	}, nil
+}
+
+func (c *commentUsecase) StoreComment(ctx context.Context, comment *domain.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, c.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	article, err := c.ArticleRepo.FindBy(ctx, "id", comment.ArticleID.String())
+	if err != nil {
+		return err
+	}
+	// Comments are open on published articles only.
+	if article.Status != domain.ArticleStatusPublished {
+		return domain.ErrNotFound
+	}
+
+	comment.Depth = 0
+	comment.RootID = uuid.Nil
+	if comment.ParentID != uuid.Nil {
+		parent, err := c.CommentRepo.Find(ctx, comment.ParentID)
+		if errors.Is(err, domain.ErrNotFound) || (err == nil && parent.ArticleID != comment.ArticleID) {
+			return domain.ErrInvalidParent
+		}
+		if err != nil {
+			return err
+		}
+
+		comment.RootID = parent.RootID
+		if comment.RootID == uuid.Nil {
+			comment.RootID = parent.ID
+		}
+
+		comment.Depth = parent.Depth + 1
+		if parent.Depth >= helper.CommentMaxDepth() {
+			// Too deep to nest any further, answer next to the parent instead.
+			comment.ParentID = parent.ParentID
+			comment.Depth = parent.Depth
+		}
+	}
+
+	now := time.Now()
+	comment.ID = uuid.New()
+	comment.AuthorID = principal.UserID
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	comment.Replies = []domain.Comment{}
+
+	return c.CommentRepo.Create(ctx, comment)
+}
+
+func (c *commentUsecase) UpdateComment(ctx context.Context, id uuid.UUID, body string) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.ContextTimeout)
+	defer cancel()
+
+	comment, err := c.CommentRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Moderators hide comments, only the author may put words in their mouth.
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != principal.UserID {
+		return nil, domain.ErrForbidden
+	}
+
+	if err := c.CommentRepo.UpdateBody(ctx, id, body); err != nil {
+		return nil, err
+	}
+	return c.find(ctx, id)
+}
+
+func (c *commentUsecase) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, c.ContextTimeout)
+	defer cancel()
+
+	comment, err := c.CommentRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != principal.UserID {
+		if _, err := c.moderatedArticle(ctx, comment); err != nil {
+			return err
+		}
+	}
+
+	return c.CommentRepo.Delete(ctx, id)
+}
+
+func (c *commentUsecase) HideComment(ctx context.Context, id uuid.UUID) (res interface{}, err error) {
+	return c.setHidden(ctx, id, true)
+}
+
+func (c *commentUsecase) UnhideComment(ctx context.Context, id uuid.UUID) (res interface{}, err error) {
+	return c.setHidden(ctx, id, false)
+}
+
+func (c *commentUsecase) setHidden(ctx context.Context, id uuid.UUID, hidden bool) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.ContextTimeout)
+	defer cancel()
+
+	comment, err := c.CommentRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := c.moderatedArticle(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.CommentRepo.SetHidden(ctx, id, hidden, principal.UserID); err != nil {
+		return nil, err
+	}
+	return c.find(ctx, id)
+}
+
+func (c *commentUsecase) find(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	comment, err := c.CommentRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	comment.Replies = []domain.Comment{}
+	return comment, nil
+}
+
+// moderatedArticle checks that the caller owns the article the comment is on, or may
+// moderate comments everywhere.
+func (c *commentUsecase) moderatedArticle(ctx context.Context, comment *domain.Comment) (*domain.Principal, error) {
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := c.ArticleRepo.FindBy(ctx, "id", comment.ArticleID.String())
+	if err != nil {
+		return nil, err
+	}
+	if article.AuthorID != principal.UserID && !principal.Can(domain.PermissionCommentsModerate) {
+		return nil, domain.ErrForbidden
+	}
+	return principal, nil
+}
+
+// canEdit tells whether the caller may see the article before it is published.
+func (c *commentUsecase) canEdit(ctx context.Context, article *domain.Article) bool {
+	principal, ok := helper.PrincipalFromContext(ctx)
+	return ok && (article.AuthorID == principal.UserID || principal.Can(domain.PermissionArticlesUpdate))
+}
+
+// viewer describes who is reading a comment thread.
+type viewer struct {
+	userID    uuid.UUID
+	moderator bool
+}
+
+func (c *commentUsecase) viewer(ctx context.Context, article *domain.Article) viewer {
+	principal, ok := helper.PrincipalFromContext(ctx)
+	if !ok {
+		return viewer{}
+	}
+	return viewer{
+		userID:    principal.UserID,
+		moderator: article.AuthorID == principal.UserID || principal.Can(domain.PermissionCommentsModerate),
+	}
+}
+
+// buildThread nests the replies below comment. Deleted comments without replies are
+// dropped, ok is false when comment itself was.
+func buildThread(comment domain.Comment, children map[uuid.UUID][]domain.Comment, v viewer) (res domain.Comment, ok bool) {
+	comment.Replies = []domain.Comment{}
+	for _, child := range children[comment.ID] {
+		if reply, ok := buildThread(child, children, v); ok {
+			comment.Replies = append(comment.Replies, reply)
+		}
+	}
+
+	deleted := !comment.DeletedAt.IsZero()
+	if deleted && len(comment.Replies) == 0 {
+		return comment, false
+	}
+
+	// Deleted comments are kept as placeholders, hidden ones are only readable by
+	// their author and the moderators.
+	hidden := !comment.HiddenAt.IsZero() && !v.moderator && comment.AuthorID != v.userID
+	if deleted || hidden {
+		comment.Body = ""
+	}
+	if deleted {
+		comment.AuthorID = uuid.Nil
+	}
+	return comment, true
+}
+
+func NewCommentUsecase(commentRepo domain.CommentRepository, articleRepo domain.ArticleRepository, duration time.Duration) domain.CommentUsecase {
+	return &commentUsecase{
+		CommentRepo:    commentRepo,
+		ArticleRepo:    articleRepo,
+		ContextTimeout: duration,
+	}
+}
@@ -0,0 +1,272 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go-boilerplate/article/repository/memory"
+	"go-boilerplate/comment/usecase"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// commentRepository keeps comments in memory, it only implements what the comment usecase uses.
+type commentRepository struct {
+	domain.CommentRepository
+	mu       sync.Mutex
+	comments map[uuid.UUID]domain.Comment
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *domain.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.comments[comment.ID] = *comment
+	return nil
+}
+
+func (r *commentRepository) Find(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || !comment.DeletedAt.IsZero() {
+		return nil, domain.ErrNotFound
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	comment.DeletedAt = pg.NullTime{Time: time.Now()}
+	r.comments[id] = comment
+	return nil
+}
+
+func (r *commentRepository) FetchThreads(ctx context.Context, articleID uuid.UUID, limit, offset int) ([]domain.Comment, int, error) {
+	var res []domain.Comment
+	for _, comment := range r.sorted() {
+		if comment.ArticleID == articleID && comment.ParentID == uuid.Nil {
+			res = append(res, comment)
+		}
+	}
+	total := len(res)
+	if offset > len(res) {
+		offset = len(res)
+	}
+	res = res[offset:]
+	if limit < len(res) {
+		res = res[:limit]
+	}
+	return res, total, nil
+}
+
+func (r *commentRepository) FetchReplies(ctx context.Context, rootIDs []uuid.UUID, limit int) ([]domain.Comment, error) {
+	counts := make(map[uuid.UUID]int)
+	for _, id := range rootIDs {
+		counts[id] = 0
+	}
+
+	var res []domain.Comment
+	for _, comment := range r.sorted() {
+		if n, ok := counts[comment.RootID]; ok && n < limit {
+			counts[comment.RootID]++
+			res = append(res, comment)
+		}
+	}
+	return res, nil
+}
+
+// sorted lists every comment oldest first.
+func (r *commentRepository) sorted() []domain.Comment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make([]domain.Comment, 0, len(r.comments))
+	for _, comment := range r.comments {
+		res = append(res, comment)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+	return res
+}
+
+var (
+	author    = &domain.Principal{UserID: uuid.New(), TokenID: uuid.New()}
+	commenter = &domain.Principal{UserID: uuid.New(), TokenID: uuid.New()}
+)
+
+func as(principal *domain.Principal) context.Context {
+	return helper.WithPrincipal(context.Background(), principal)
+}
+
+type fixture struct {
+	article  *domain.Article
+	comments *commentRepository
+	usecase  domain.CommentUsecase
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	articles := memory.NewMemoryArticleRepository()
+	article := &domain.Article{ID: uuid.New(), Title: "Threads", Slug: "threads", AuthorID: author.UserID,
+		Status: domain.ArticleStatusPublished, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := articles.Create(context.Background(), article); err != nil {
+		t.Fatal(err)
+	}
+
+	comments := &commentRepository{comments: map[uuid.UUID]domain.Comment{}}
+	return &fixture{
+		article:  article,
+		comments: comments,
+		usecase:  usecase.NewCommentUsecase(comments, articles, time.Second),
+	}
+}
+
+// reply posts body as commenter below parent, or as a top level comment when parent is nil.
+func (f *fixture) reply(t *testing.T, parent *domain.Comment, body string) *domain.Comment {
+	t.Helper()
+	comment := &domain.Comment{ArticleID: f.article.ID, Body: body}
+	if parent != nil {
+		comment.ParentID = parent.ID
+	}
+	if err := f.usecase.StoreComment(as(commenter), comment); err != nil {
+		t.Fatalf("reply %q: %v", body, err)
+	}
+	// Keep the creation order visible to the repository.
+	time.Sleep(time.Millisecond)
+	return comment
+}
+
+func (f *fixture) threads(t *testing.T) []domain.Comment {
+	t.Helper()
+	res, err := f.usecase.FetchComments(context.Background(), f.article.ID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.(*domain.CommentPage).Threads
+}
+
+func TestStoreCommentNesting(t *testing.T) {
+	viper.Set("COMMENT_MAX_DEPTH", 2)
+	defer viper.Set("COMMENT_MAX_DEPTH", nil)
+
+	f := newFixture(t)
+	root := f.reply(t, nil, "root")
+	child := f.reply(t, root, "child")
+	grandchild := f.reply(t, child, "grandchild")
+	tooDeep := f.reply(t, grandchild, "too deep")
+
+	tests := []struct {
+		name       string
+		comment    *domain.Comment
+		wantParent uuid.UUID
+		wantDepth  int
+	}{
+		{name: "top level", comment: root, wantParent: uuid.Nil, wantDepth: 0},
+		{name: "reply", comment: child, wantParent: root.ID, wantDepth: 1},
+		{name: "reply at the deepest level", comment: grandchild, wantParent: child.ID, wantDepth: 2},
+		{name: "reply below the deepest level", comment: tooDeep, wantParent: child.ID, wantDepth: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.comment.ParentID != tt.wantParent || tt.comment.Depth != tt.wantDepth {
+				t.Errorf("parent %s at depth %d, want %s at depth %d", tt.comment.ParentID, tt.comment.Depth, tt.wantParent, tt.wantDepth)
+			}
+			wantRoot := root.ID
+			if tt.comment == root {
+				wantRoot = uuid.Nil
+			}
+			if tt.comment.RootID != wantRoot {
+				t.Errorf("root = %s, want %s", tt.comment.RootID, wantRoot)
+			}
+		})
+	}
+}
+
+func TestStoreCommentRejectsForeignParent(t *testing.T) {
+	f := newFixture(t)
+	foreign := &domain.Comment{ID: uuid.New(), ArticleID: uuid.New(), CreatedAt: time.Now()}
+	if err := f.comments.Create(context.Background(), foreign); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, parentID := range map[string]uuid.UUID{"unknown parent": uuid.New(), "parent on another article": foreign.ID} {
+		t.Run(name, func(t *testing.T) {
+			err := f.usecase.StoreComment(as(commenter), &domain.Comment{ArticleID: f.article.ID, ParentID: parentID, Body: "x"})
+			if !errors.Is(err, domain.ErrInvalidParent) {
+				t.Errorf("err = %v, want %v", err, domain.ErrInvalidParent)
+			}
+		})
+	}
+}
+
+func TestFetchCommentsBuildsThreads(t *testing.T) {
+	f := newFixture(t)
+	root := f.reply(t, nil, "root")
+	kept := f.reply(t, root, "kept")
+	f.reply(t, kept, "answer")
+	gone := f.reply(t, root, "gone")
+	if err := f.usecase.DeleteComment(as(commenter), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	parent := f.reply(t, root, "deleted parent")
+	f.reply(t, parent, "orphan")
+	if err := f.usecase.DeleteComment(as(commenter), parent.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	threads := f.threads(t)
+	if len(threads) != 1 {
+		t.Fatalf("got %d threads, want 1", len(threads))
+	}
+	replies := threads[0].Replies
+	if len(replies) != 2 {
+		t.Fatalf("got %d replies, want the kept one and the deleted parent", len(replies))
+	}
+	if replies[0].Body != "kept" || len(replies[0].Replies) != 1 || replies[0].Replies[0].Body != "answer" {
+		t.Errorf("first reply = %+v, want kept with its answer", replies[0])
+	}
+	if replies[1].Body != "" || replies[1].AuthorID != uuid.Nil || len(replies[1].Replies) != 1 {
+		t.Errorf("deleted parent = %+v, want a placeholder keeping its reply", replies[1])
+	}
+}
+
+func TestFetchCommentsCapsRepliesPerThread(t *testing.T) {
+	viper.Set("COMMENT_MAX_REPLIES", 2)
+	defer viper.Set("COMMENT_MAX_REPLIES", nil)
+
+	f := newFixture(t)
+	busy := f.reply(t, nil, "busy")
+	first := f.reply(t, busy, "first")
+	f.reply(t, first, "second")
+	f.reply(t, busy, "third")
+	quiet := f.reply(t, nil, "quiet")
+	f.reply(t, quiet, "only")
+
+	for _, thread := range f.threads(t) {
+		want := map[string]int{"busy": 2, "quiet": 1}[thread.Body]
+		if got := countReplies(thread); got != want {
+			t.Errorf("thread %q lists %d replies, want %d", thread.Body, got, want)
+		}
+	}
+}
+
+func countReplies(comment domain.Comment) (n int) {
+	for _, reply := range comment.Replies {
+		n += 1 + countReplies(reply)
+	}
+	return n
+}
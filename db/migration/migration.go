@@ -0,0 +1,376 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey is the advisory lock id held while a migration batch runs, so two
+// deploys can not migrate the same database at the same time.
+const lockKey = 720136
+
+var fileName = regexp.MustCompile(`^((\d{4}_\d{2}_\d{2}_\d{6})_\w+)\.(up|down)\.sql$`)
+
+type (
+	// Migration is a single versioned schema change embedded in the binary.
+	Migration struct {
+		Version  string
+		Name     string
+		Up       string
+		Down     string
+		Checksum string
+	}
+
+	// Record is a row of the migrations table.
+	Record struct {
+		tableName struct{} `pg:"migrations"`
+		ID        int      `pg:"id,pk"`
+		Migration string   `pg:"migration"`
+		Batch     int      `pg:"batch"`
+		Checksum  string   `pg:"checksum"`
+	}
+
+	// Status reports whether a migration has been applied and whether the
+	// applied SQL still matches the embedded file.
+	Status struct {
+		Migration
+		Applied bool
+		Batch   int
+		Drift   bool
+	}
+
+	Migrator struct {
+		DB         *pg.DB
+		Migrations []Migration
+	}
+)
+
+// Load reads every <version>_<name>.up.sql / .down.sql pair in dir, ordered by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byName[match[1]]
+		if !ok {
+			m = &Migration{Name: match[1], Version: match[2]}
+			byName[match[1]] = m
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byName))
+	for _, m := range byName {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Name < migrations[j].Name
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %s", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// NewMigrator returns a Migrator for the SQL files embedded in this package.
+func NewMigrator(db *pg.DB) (*Migrator, error) {
+	migrations, err := Load(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// Up applies every pending migration as a single batch.
+func (m *Migrator) Up(ctx context.Context) (res []Migration, err error) {
+	err = m.run(ctx, func(tx *pg.Tx, applied map[string]Record) error {
+		res, err = m.apply(ctx, tx, applied, m.pending(applied, ""))
+		return err
+	})
+	return res, err
+}
+
+// Down rolls back the most recent batch.
+func (m *Migrator) Down(ctx context.Context) (res []Migration, err error) {
+	err = m.run(ctx, func(tx *pg.Tx, applied map[string]Record) error {
+		last := 0
+		for _, rec := range applied {
+			if rec.Batch > last {
+				last = rec.Batch
+			}
+		}
+
+		var names []string
+		for name, rec := range applied {
+			if last > 0 && rec.Batch == last {
+				names = append(names, name)
+			}
+		}
+
+		res, err = m.rollback(ctx, tx, applied, names)
+		return err
+	})
+	return res, err
+}
+
+// To migrates up or down until version is the latest applied migration.
+// Version "0" rolls back everything.
+func (m *Migrator) To(ctx context.Context, version string) (up, down []Migration, err error) {
+	if version != "0" && m.find(version) == nil {
+		return nil, nil, fmt.Errorf("unknown migration version %s", version)
+	}
+
+	err = m.run(ctx, func(tx *pg.Tx, applied map[string]Record) error {
+		var names []string
+		for name := range applied {
+			if version == "0" || versionOf(name) > version {
+				names = append(names, name)
+			}
+		}
+
+		if down, err = m.rollback(ctx, tx, applied, names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			delete(applied, name)
+		}
+
+		up, err = m.apply(ctx, tx, applied, m.pending(applied, version))
+		return err
+	})
+	return up, down, err
+}
+
+// Status lists the embedded migrations and any applied ones that are no longer shipped.
+func (m *Migrator) Status(ctx context.Context) (res []Status, err error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if err := m.DB.ModelContext(ctx, &records).Order("id ASC").Select(); err != nil {
+		return nil, err
+	}
+
+	applied := map[string]Record{}
+	for _, rec := range records {
+		applied[rec.Migration] = rec
+	}
+
+	for _, mig := range m.Migrations {
+		rec, ok := applied[mig.Name]
+		res = append(res, Status{
+			Migration: mig,
+			Applied:   ok,
+			Batch:     rec.Batch,
+			Drift:     ok && rec.Checksum != "" && rec.Checksum != mig.Checksum,
+		})
+		delete(applied, mig.Name)
+	}
+
+	for _, rec := range records {
+		if _, missing := applied[rec.Migration]; missing {
+			res = append(res, Status{
+				Migration: Migration{Name: rec.Migration},
+				Applied:   true,
+				Batch:     rec.Batch,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+func (m *Migrator) run(ctx context.Context, fn func(tx *pg.Tx, applied map[string]Record) error) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	return m.DB.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", lockKey); err != nil {
+			return err
+		}
+
+		var records []Record
+		if err := tx.ModelContext(ctx, &records).Select(); err != nil {
+			return err
+		}
+
+		applied := map[string]Record{}
+		var drifted []string
+		for _, rec := range records {
+			applied[rec.Migration] = rec
+
+			mig := m.byName(rec.Migration)
+			if mig == nil {
+				continue
+			}
+
+			// Rows written by the old Laravel schema have no checksum, adopt the embedded one.
+			if rec.Checksum == "" {
+				_, err := tx.ModelContext(ctx, &rec).Set("checksum = ?", mig.Checksum).WherePK().Update()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if rec.Checksum != mig.Checksum {
+				drifted = append(drifted, rec.Migration)
+			}
+		}
+
+		if len(drifted) > 0 {
+			return fmt.Errorf("checksum drift detected, applied migrations were modified: %s", strings.Join(drifted, ", "))
+		}
+
+		return fn(tx, applied)
+	})
+}
+
+func (m *Migrator) apply(ctx context.Context, tx *pg.Tx, applied map[string]Record, pending []Migration) ([]Migration, error) {
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	batch := 0
+	for _, rec := range applied {
+		if rec.Batch > batch {
+			batch = rec.Batch
+		}
+	}
+	batch++
+
+	for _, mig := range pending {
+		if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+			return nil, fmt.Errorf("%s: %w", mig.Name, err)
+		}
+
+		rec := &Record{Migration: mig.Name, Batch: batch, Checksum: mig.Checksum}
+		if _, err := tx.ModelContext(ctx, rec).Insert(); err != nil {
+			return nil, err
+		}
+	}
+
+	return pending, nil
+}
+
+func (m *Migrator) rollback(ctx context.Context, tx *pg.Tx, applied map[string]Record, names []string) ([]Migration, error) {
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	var res []Migration
+	for _, name := range names {
+		mig := m.byName(name)
+		if mig == nil {
+			return nil, fmt.Errorf("can not roll back %s, migration file is missing", name)
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("can not roll back %s, migration has no down file", name)
+		}
+
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			return nil, fmt.Errorf("%s: %w", mig.Name, err)
+		}
+
+		rec := applied[name]
+		if _, err := tx.ModelContext(ctx, &rec).WherePK().Delete(); err != nil {
+			return nil, err
+		}
+
+		res = append(res, *mig)
+	}
+
+	return res, nil
+}
+
+// pending returns the unapplied migrations up to and including version, or all of them when version is empty.
+func (m *Migrator) pending(applied map[string]Record, version string) []Migration {
+	var res []Migration
+	for _, mig := range m.Migrations {
+		if version != "" && mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Name]; !ok {
+			res = append(res, mig)
+		}
+	}
+	return res
+}
+
+func versionOf(name string) string {
+	if len(name) < len("2006_01_02_150405") {
+		return name
+	}
+	return name[:len("2006_01_02_150405")]
+}
+
+func (m *Migrator) find(version string) *Migration {
+	for i := range m.Migrations {
+		if m.Migrations[i].Version == version {
+			return &m.Migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) byName(name string) *Migration {
+	for i := range m.Migrations {
+		if m.Migrations[i].Name == name {
+			return &m.Migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS migrations (
+			id serial PRIMARY KEY,
+			migration character varying(255) NOT NULL,
+			batch integer NOT NULL
+		);
+		ALTER TABLE migrations ADD COLUMN IF NOT EXISTS checksum character varying(64);
+	`)
+	return err
+}
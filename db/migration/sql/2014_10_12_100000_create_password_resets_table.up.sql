@@ -0,0 +1,7 @@
+CREATE TABLE password_resets (
+    email character varying(255) NOT NULL,
+    token character varying(255) NOT NULL,
+    created_at timestamp(0) without time zone
+);
+
+CREATE INDEX password_resets_email_index ON password_resets USING btree (email);
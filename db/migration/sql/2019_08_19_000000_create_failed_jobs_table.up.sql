@@ -0,0 +1,11 @@
+CREATE TABLE failed_jobs (
+    id bigserial NOT NULL,
+    uuid character varying(255) NOT NULL,
+    connection text NOT NULL,
+    queue text NOT NULL,
+    payload text NOT NULL,
+    exception text NOT NULL,
+    failed_at timestamp(0) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT failed_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT failed_jobs_uuid_unique UNIQUE (uuid)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE articles (
+    id uuid NOT NULL,
+    title character varying(255) NOT NULL,
+    slug character varying(255) NOT NULL,
+    description text NOT NULL,
+    deleted_at timestamp(0) without time zone,
+    created_at timestamp(0) without time zone,
+    updated_at timestamp(0) without time zone,
+    CONSTRAINT articles_pkey PRIMARY KEY (id)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE articles ADD COLUMN author_id uuid;
+
+ALTER TABLE articles
+    ADD CONSTRAINT articles_author_id_foreign FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX articles_author_id_index ON articles USING btree (author_id);
@@ -0,0 +1,18 @@
+CREATE TABLE refresh_tokens (
+    id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    family_id uuid NOT NULL,
+    token_hash character varying(64) NOT NULL,
+    access_token_id uuid NOT NULL,
+    expires_at timestamp(0) without time zone NOT NULL,
+    used_at timestamp(0) without time zone,
+    revoked_at timestamp(0) without time zone,
+    created_at timestamp(0) without time zone,
+    CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT refresh_tokens_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT refresh_tokens_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_id_index ON refresh_tokens USING btree (family_id);
+
+CREATE INDEX refresh_tokens_access_token_id_index ON refresh_tokens USING btree (access_token_id);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN email_verification_sent_at timestamp(0) without time zone;
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN is_admin boolean DEFAULT false NOT NULL;
+
+UPDATE users SET is_admin = true
+WHERE id IN (
+    SELECT user_roles.user_id FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE roles.name = 'admin'
+);
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
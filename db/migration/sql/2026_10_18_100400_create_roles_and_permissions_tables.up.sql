@@ -0,0 +1,52 @@
+CREATE TABLE roles (
+    id uuid NOT NULL,
+    name character varying(255) NOT NULL,
+    created_at timestamp(0) without time zone,
+    updated_at timestamp(0) without time zone,
+    CONSTRAINT roles_pkey PRIMARY KEY (id),
+    CONSTRAINT roles_name_unique UNIQUE (name)
+);
+
+CREATE TABLE permissions (
+    id uuid NOT NULL,
+    name character varying(255) NOT NULL,
+    description character varying(255),
+    CONSTRAINT permissions_pkey PRIMARY KEY (id),
+    CONSTRAINT permissions_name_unique UNIQUE (name)
+);
+
+CREATE TABLE role_permissions (
+    role_id uuid NOT NULL,
+    permission_id uuid NOT NULL,
+    CONSTRAINT role_permissions_pkey PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT role_permissions_role_id_foreign FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+    CONSTRAINT role_permissions_permission_id_foreign FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles (
+    user_id uuid NOT NULL,
+    role_id uuid NOT NULL,
+    CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role_id),
+    CONSTRAINT user_roles_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT user_roles_role_id_foreign FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_roles_role_id_index ON user_roles USING btree (role_id);
+
+INSERT INTO permissions (id, name, description) VALUES
+    (gen_random_uuid(), 'users:read', 'List every user'),
+    (gen_random_uuid(), 'users:delete', 'Delete, restore and purge any user'),
+    (gen_random_uuid(), 'articles:update', 'Edit any article'),
+    (gen_random_uuid(), 'articles:delete', 'Delete, restore and purge any article and list trashed ones'),
+    (gen_random_uuid(), 'roles:manage', 'Manage roles, permissions and role assignments');
+
+INSERT INTO roles (id, name, created_at, updated_at) VALUES
+    (gen_random_uuid(), 'admin', now(), now());
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions WHERE roles.name = 'admin';
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT users.id, roles.id FROM users CROSS JOIN roles WHERE users.is_admin AND roles.name = 'admin';
+
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,18 @@
+-- Older articles may share a slug, keep the oldest as is and number the rest.
+UPDATE articles SET slug = duplicates.slug || '-' || duplicates.n
+FROM (
+    SELECT id, slug, row_number() OVER (PARTITION BY slug ORDER BY created_at, id) AS n FROM articles
+) AS duplicates
+WHERE articles.id = duplicates.id AND duplicates.n > 1;
+
+CREATE UNIQUE INDEX articles_slug_unique ON articles USING btree (slug);
+
+CREATE TABLE article_slugs (
+    slug character varying(255) NOT NULL,
+    article_id uuid NOT NULL,
+    created_at timestamp(0) without time zone,
+    CONSTRAINT article_slugs_pkey PRIMARY KEY (slug),
+    CONSTRAINT article_slugs_article_id_foreign FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX article_slugs_article_id_index ON article_slugs USING btree (article_id);
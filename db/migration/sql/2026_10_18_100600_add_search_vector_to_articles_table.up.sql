@@ -0,0 +1,8 @@
+ALTER TABLE articles ADD COLUMN language regconfig DEFAULT 'english' NOT NULL;
+
+ALTER TABLE articles ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(language, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(language, coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX articles_search_vector_index ON articles USING gin (search_vector);
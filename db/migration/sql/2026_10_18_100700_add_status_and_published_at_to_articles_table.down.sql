@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS articles_status_published_at_index;
+
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS articles_status_check;
+ALTER TABLE articles DROP COLUMN IF EXISTS published_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS status;
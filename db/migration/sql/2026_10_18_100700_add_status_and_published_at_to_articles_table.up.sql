@@ -0,0 +1,10 @@
+ALTER TABLE articles ADD COLUMN status character varying(20) DEFAULT 'draft' NOT NULL;
+ALTER TABLE articles ADD COLUMN published_at timestamp(0) without time zone;
+
+ALTER TABLE articles ADD CONSTRAINT articles_status_check
+    CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+
+-- Everything written before the workflow existed was live already.
+UPDATE articles SET status = 'published', published_at = created_at;
+
+CREATE INDEX articles_status_published_at_index ON articles USING btree (status, published_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS article_revisions;
+
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS articles_updated_by_foreign;
+ALTER TABLE articles DROP COLUMN IF EXISTS updated_by;
@@ -0,0 +1,18 @@
+ALTER TABLE articles ADD COLUMN updated_by uuid;
+ALTER TABLE articles ADD CONSTRAINT articles_updated_by_foreign FOREIGN KEY (updated_by) REFERENCES users (id) ON DELETE SET NULL;
+
+UPDATE articles SET updated_by = author_id;
+
+CREATE TABLE article_revisions (
+    id uuid NOT NULL,
+    article_id uuid NOT NULL,
+    revision integer NOT NULL,
+    title character varying(255) NOT NULL,
+    description text NOT NULL,
+    editor_id uuid,
+    created_at timestamp(0) without time zone,
+    CONSTRAINT article_revisions_pkey PRIMARY KEY (id),
+    CONSTRAINT article_revisions_article_id_revision_unique UNIQUE (article_id, revision),
+    CONSTRAINT article_revisions_article_id_foreign FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+    CONSTRAINT article_revisions_editor_id_foreign FOREIGN KEY (editor_id) REFERENCES users (id) ON DELETE SET NULL
+);
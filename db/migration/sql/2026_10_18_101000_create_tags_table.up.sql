@@ -0,0 +1,19 @@
+CREATE TABLE tags (
+    id uuid NOT NULL,
+    name character varying(50) NOT NULL,
+    slug character varying(60) NOT NULL,
+    created_at timestamp(0) without time zone,
+    updated_at timestamp(0) without time zone,
+    CONSTRAINT tags_pkey PRIMARY KEY (id),
+    CONSTRAINT tags_slug_unique UNIQUE (slug)
+);
+
+CREATE TABLE article_tags (
+    article_id uuid NOT NULL,
+    tag_id uuid NOT NULL,
+    CONSTRAINT article_tags_pkey PRIMARY KEY (article_id, tag_id),
+    CONSTRAINT article_tags_article_id_foreign FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+    CONSTRAINT article_tags_tag_id_foreign FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE INDEX article_tags_tag_id_index ON article_tags (tag_id);
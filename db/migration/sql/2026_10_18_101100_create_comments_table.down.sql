@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'comments:moderate';
+
+DROP TABLE IF EXISTS comments;
@@ -0,0 +1,30 @@
+CREATE TABLE comments (
+    id uuid NOT NULL,
+    article_id uuid NOT NULL,
+    parent_id uuid,
+    root_id uuid,
+    author_id uuid,
+    body text NOT NULL,
+    depth integer DEFAULT 0 NOT NULL,
+    hidden_at timestamp(0) without time zone,
+    hidden_by uuid,
+    created_at timestamp(0) without time zone,
+    updated_at timestamp(0) without time zone,
+    deleted_at timestamp(0) without time zone,
+    CONSTRAINT comments_pkey PRIMARY KEY (id),
+    CONSTRAINT comments_article_id_foreign FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+    CONSTRAINT comments_parent_id_foreign FOREIGN KEY (parent_id) REFERENCES comments (id) ON DELETE CASCADE,
+    CONSTRAINT comments_root_id_foreign FOREIGN KEY (root_id) REFERENCES comments (id) ON DELETE CASCADE,
+    CONSTRAINT comments_author_id_foreign FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT comments_hidden_by_foreign FOREIGN KEY (hidden_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX comments_article_id_created_at_index ON comments (article_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX comments_root_id_index ON comments (root_id);
+
+INSERT INTO permissions (id, name, description) VALUES
+    (gen_random_uuid(), 'comments:moderate', 'Hide and delete comments on any article');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'comments:moderate';
@@ -0,0 +1,3 @@
+ALTER TABLE articles DROP COLUMN IF EXISTS reading_time;
+ALTER TABLE articles DROP COLUMN IF EXISTS excerpt;
+ALTER TABLE articles DROP COLUMN IF EXISTS description_html;
@@ -0,0 +1,9 @@
+ALTER TABLE articles ADD COLUMN description_html text;
+ALTER TABLE articles ADD COLUMN excerpt character varying(300);
+ALTER TABLE articles ADD COLUMN reading_time integer DEFAULT 0 NOT NULL;
+
+-- Existing descriptions are rendered by the application the first time they are read,
+-- the plain text estimates below only have to hold until the article is next saved.
+UPDATE articles SET
+    excerpt = left(regexp_replace(trim(description), '\s+', ' ', 'g'), 280),
+    reading_time = ceil(coalesce(array_length(regexp_split_to_array(trim(description), '\s+'), 1), 0) / 200.0);
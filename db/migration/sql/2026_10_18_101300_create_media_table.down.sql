@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE name = 'media:manage';
+
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS articles_cover_id_foreign;
+ALTER TABLE articles DROP COLUMN IF EXISTS cover_id;
+
+DROP TABLE IF EXISTS media;
@@ -0,0 +1,27 @@
+CREATE TABLE media (
+    id uuid NOT NULL,
+    owner_id uuid,
+    path character varying(255) NOT NULL,
+    thumbnail_path character varying(255),
+    filename character varying(255) NOT NULL,
+    mime_type character varying(100) NOT NULL,
+    size bigint NOT NULL,
+    width integer,
+    height integer,
+    created_at timestamp(0) without time zone,
+    updated_at timestamp(0) without time zone,
+    CONSTRAINT media_pkey PRIMARY KEY (id),
+    CONSTRAINT media_owner_id_foreign FOREIGN KEY (owner_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX media_owner_id_index ON media (owner_id);
+
+ALTER TABLE articles ADD COLUMN cover_id uuid;
+ALTER TABLE articles ADD CONSTRAINT articles_cover_id_foreign FOREIGN KEY (cover_id) REFERENCES media (id) ON DELETE SET NULL;
+
+INSERT INTO permissions (id, name, description) VALUES
+    (gen_random_uuid(), 'media:manage', 'Delete media uploaded by anyone');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'media:manage';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS article_views_daily;
+
+ALTER TABLE articles DROP COLUMN IF EXISTS view_count;
@@ -0,0 +1,11 @@
+ALTER TABLE articles ADD COLUMN view_count bigint DEFAULT 0 NOT NULL;
+
+CREATE TABLE article_views_daily (
+    article_id uuid NOT NULL,
+    day date NOT NULL,
+    views bigint DEFAULT 0 NOT NULL,
+    CONSTRAINT article_views_daily_pkey PRIMARY KEY (article_id, day),
+    CONSTRAINT article_views_daily_article_id_foreign FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX article_views_daily_day_index ON article_views_daily (day);
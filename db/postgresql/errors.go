@@ -0,0 +1,25 @@
+package postgresql
+
+import (
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"go-boilerplate/domain"
+)
+
+// TranslateError turns the query errors usecases act on into domain errors. A missing
+// row becomes domain.ErrNotFound and a unique violation the error uniques holds for the
+// violated constraint, or domain.ErrConflict. Other errors are returned unchanged.
+func TranslateError(err error, uniques map[string]error) error {
+	if errors.Is(err, pg.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == "23505" {
+		if translated, ok := uniques[pgErr.Field('n')]; ok {
+			return translated
+		}
+		return domain.ErrConflict
+	}
+	return err
+}
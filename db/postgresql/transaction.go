@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"go-boilerplate/domain"
+)
+
+// RunInTransaction runs fn in a new transaction on db. When db already is a transaction
+// fn runs in a savepoint instead, so its failure is undone without aborting the caller's.
+func RunInTransaction(ctx context.Context, db orm.DB, fn func(tx *pg.Tx) error) error {
+	switch db := db.(type) {
+	case *pg.Tx:
+		return runInSavepoint(ctx, db, fn)
+	case *pg.DB:
+		return db.RunInTransaction(ctx, fn)
+	}
+	return fmt.Errorf("postgresql: can not start a transaction on %T", db)
+}
+
+func runInSavepoint(ctx context.Context, tx *pg.Tx, fn func(tx *pg.Tx) error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested"); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT nested")
+	return err
+}
+
+type txKey struct{}
+
+// Conn is the transaction a Transactor started for ctx, or db when there is none.
+// Repositories taking part in such transactions query through it.
+func Conn(ctx context.Context, db orm.DB) orm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*pg.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type transactor struct {
+	DB orm.DB
+}
+
+// NewTransactor returns a domain.Transactor starting its transactions on db.
+func NewTransactor(db orm.DB) domain.Transactor {
+	return transactor{DB: db}
+}
+
+func (t transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTransaction(ctx, Conn(ctx, t.DB), func(tx *pg.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -0,0 +1,48 @@
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/db/dbtest"
+	database "go-boilerplate/db/postgresql"
+	"testing"
+)
+
+func TestTransactor(t *testing.T) {
+	db := dbtest.Open(t)
+	if db == nil {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	tx := dbtest.Tx(t, db)
+	if _, err := tx.Exec("CREATE TEMPORARY TABLE transactor_test (id int)"); err != nil {
+		t.Fatal(err)
+	}
+
+	transactor := database.NewTransactor(tx)
+	insert := func(ctx context.Context) error {
+		_, err := database.Conn(ctx, db).ExecContext(ctx, "INSERT INTO transactor_test VALUES (1)")
+		return err
+	}
+	failed := errors.New("failed")
+
+	err := transactor.Transaction(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	if err := transactor.Transaction(context.Background(), insert); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows int
+	if _, err := tx.QueryOne(&rows, "SELECT count(*) FROM transactor_test"); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 {
+		t.Errorf("got %d rows, want only the one of the committed transaction", rows)
+	}
+}
@@ -2,34 +2,215 @@ package domain
 
 import (
 	"context"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 	"time"
 )
 
+// Article statuses, only published articles are visible to the public.
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusScheduled = "scheduled"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
 type (
 	Article struct {
-		tableName 	struct{} 	`pg:"articles"`
-		ID        	uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
-		Title      	string   `pg:"title,type:varchar(255)" json:"name" form:"title"`
-		Slug     	string  `pg:"slug,type:varchar(255)" json:"slug"`
-		Description string 	`pg:"description" json:"description"`
+		tableName struct{}  `pg:"articles"`
+		ID        uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		Title     string    `pg:"title,type:varchar(255)" json:"name" form:"title"`
+		Slug      string    `pg:"slug,type:varchar(255)" json:"slug"`
+		// Description is the Markdown source, DescriptionHTML what it renders to after sanitizing.
+		Description     string      `pg:"description" json:"description,omitempty"`
+		DescriptionHTML string      `pg:"description_html" json:"descriptionHtml,omitempty"`
+		Excerpt         string      `pg:"excerpt,type:varchar(300)" json:"excerpt"`
+		ReadingTime     int         `pg:"reading_time" json:"readingTime"`
+		AuthorID        uuid.UUID   `pg:"author_id,type:uuid" json:"authorId"`
+		Language        string      `pg:"language" json:"language"`
+		Status          string      `pg:"status,type:varchar(20)" json:"status"`
+		PublishedAt     pg.NullTime `pg:"published_at" json:"publishedAt"`
+		UpdatedBy       uuid.UUID   `pg:"updated_by,type:uuid" json:"updatedBy"`
+		CoverID         uuid.UUID   `pg:"cover_id,type:uuid" json:"coverId"`
+		ViewCount       int64       `pg:"view_count" json:"views"`
+		// Version is bumped on every write. On Update and Delete a non-zero version is
+		// the one the caller expects the article to be at.
+		Version   int         `pg:"version" json:"version"`
+		CreatedAt time.Time   `pg:"created_at" json:"createdAt"`
+		UpdatedAt time.Time   `pg:"updated_at" json:"updatedAt"`
+		DeletedAt pg.NullTime `pg:"deleted_at,soft_delete" json:"deletedAt"`
+		// Tags are stored in article_tags. On Create and Update a nil slice leaves them as they are.
+		Tags  []Tag  `pg:"-" json:"tags"`
+		Cover *Media `pg:"-" json:"cover"`
+	}
+
+	// ArticleSlug is a slug an article was reachable under before its title changed.
+	ArticleSlug struct {
+		tableName struct{}  `pg:"article_slugs"`
+		Slug      string    `pg:"slug,pk,type:varchar(255)" json:"slug"`
+		ArticleID uuid.UUID `pg:"article_id,type:uuid" json:"articleId"`
+		CreatedAt time.Time `pg:"created_at" json:"createdAt"`
+	}
+
+	// ArticleRevision is a snapshot of an article's content before it was edited.
+	ArticleRevision struct {
+		tableName   struct{}  `pg:"article_revisions"`
+		ID          uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		ArticleID   uuid.UUID `pg:"article_id,type:uuid" json:"articleId"`
+		Revision    int       `pg:"revision" json:"revision"`
+		Title       string    `pg:"title,type:varchar(255)" json:"title"`
+		Description string    `pg:"description" json:"description"`
+		// EditorID is the user who wrote this version of the content.
+		EditorID  uuid.UUID `pg:"editor_id,type:uuid" json:"editorId"`
 		CreatedAt time.Time `pg:"created_at" json:"createdAt"`
-		UpdatedAt time.Time `pg:"updated_at" json:"updatedAt"`
+	}
+
+	// ArticleDiff is the line diff of an article's title and description between two revisions.
+	ArticleDiff struct {
+		From        int        `json:"from"`
+		To          int        `json:"to"`
+		Title       []DiffLine `json:"title"`
+		Description []DiffLine `json:"description"`
+	}
+
+	// ArticleFilter narrows down and orders an article listing.
+	ArticleFilter struct {
+		Limit       int
+		Offset      int
+		Cursor      string
+		AuthorID    uuid.UUID
+		Title       string
+		CreatedFrom time.Time
+		CreatedTo   time.Time
+		Sort        string
+		Order       string
+		// Trashed is "with" to include soft deleted articles or "only" to list nothing else.
+		Trashed string
+		// Status defaults to published, other statuses are only listed for the caller's
+		// own articles or with the articles:update permission.
+		Status string
+		// Tag is the slug of a tag the articles must carry.
+		Tag string
+	}
+
+	// ArticleCursor is the decoded form of a keyset pagination cursor. It points
+	// at the row a page starts after, or before when Backward is set.
+	ArticleCursor struct {
+		Sort     string    `json:"s"`
+		Order    string    `json:"o"`
+		Value    string    `json:"v"`
+		ID       uuid.UUID `json:"id"`
+		Backward bool      `json:"b,omitempty"`
+	}
 
+	// ArticleSearch is a full-text query over article titles and descriptions.
+	ArticleSearch struct {
+		Query string
+		// Language is the text search configuration the query is parsed with.
+		Language string
+		Limit    int
+		Offset   int
+	}
+
+	// ArticleSearchResult is a matching article with its rank and highlighted snippets.
+	ArticleSearchResult struct {
+		Article
+		Rank          float64 `pg:"rank" json:"rank"`
+		TitleHeadline string  `pg:"title_headline" json:"titleHeadline"`
+		Headline      string  `pg:"headline" json:"headline"`
+	}
+
+	// ArticleViews is how often an article was read on one day.
+	ArticleViews struct {
+		tableName struct{}  `pg:"article_views_daily"`
+		ArticleID uuid.UUID `pg:"article_id,pk,type:uuid"`
+		Day       time.Time `pg:"day,pk,type:date"`
+		Views     int64     `pg:"views,use_zero"`
+	}
+
+	// PopularArticle is an article with the views it got within the ranking window.
+	PopularArticle struct {
+		Article
+		RecentViews int64 `pg:"recent_views" json:"recentViews"`
+	}
+
+	ArticleSearchPage struct {
+		Articles []ArticleSearchResult `json:"articles"`
+		Meta     PageMeta              `json:"meta"`
+	}
+
+	ArticlePage struct {
+		Articles []Article `json:"articles"`
+		Meta     PageMeta  `json:"meta"`
 	}
 
 	ArticleRepository interface {
 		Create(ctx context.Context, ar *Article) error
-		Delete(ctx context.Context, id uuid.UUID) error
+		Delete(ctx context.Context, id uuid.UUID, version int) error
+		Fetch(ctx context.Context, filter *ArticleFilter, cursor *ArticleCursor) (res []Article, total int, err error)
 		FindBy(ctx context.Context, key, value string) (ar *Article, err error)
+		// FindByOldSlug returns the article that used to be reachable under slug.
+		FindByOldSlug(ctx context.Context, slug string) (ar *Article, err error)
+		// TakenSlugs lists the current and old slugs equal to base or starting with "base-",
+		// ignoring those of the article exceptID.
+		TakenSlugs(ctx context.Context, base string, exceptID uuid.UUID) (res []string, err error)
+		FindWithTrashed(ctx context.Context, id uuid.UUID) (ar *Article, err error)
+		ForceDelete(ctx context.Context, id uuid.UUID) error
+		Restore(ctx context.Context, id uuid.UUID) error
+		Search(ctx context.Context, search *ArticleSearch) (res []ArticleSearchResult, total int, err error)
+		SetStatus(ctx context.Context, id uuid.UUID, status string, publishedAt pg.NullTime) error
+		// PublishDue publishes every scheduled article whose time is before now.
+		PublishDue(ctx context.Context, now time.Time) (published int, err error)
+		// NextScheduled returns when the next scheduled article is due, ok is false when none is.
+		NextScheduled(ctx context.Context) (at time.Time, ok bool, err error)
+		// Anonymize detaches every article, trashed ones included, from authorID.
+		Anonymize(ctx context.Context, authorID uuid.UUID) error
 		Update(ctx context.Context, id uuid.UUID, art *Article) (ar *Article, err error)
+		FetchRevisions(ctx context.Context, articleID uuid.UUID) (res []ArticleRevision, err error)
+		FindRevision(ctx context.Context, articleID uuid.UUID, revision int) (rev *ArticleRevision, err error)
+		// AddViews adds to the daily and total view counts in one transaction.
+		AddViews(ctx context.Context, views []ArticleViews) error
+		// Popular ranks published articles by their views since the start of day since.
+		Popular(ctx context.Context, since time.Time, limit int) (res []PopularArticle, err error)
 	}
 
 	ArticleUsecase interface {
 		CreateArticle(ctx context.Context, article *Article) error
 		UpdateArticle(ctx context.Context, id uuid.UUID, article *Article) (res interface{}, err error)
-		DeleteArticle(ctx context.Context, id uuid.UUID) error
-		GetArticleBySlug(ctx context.Context, id string) (res interface{}, err error)
+		DeleteArticle(ctx context.Context, id uuid.UUID, version int) error
+		FetchArticles(ctx context.Context, filter *ArticleFilter) (res interface{}, err error)
+		// GetArticleBySlug also finds articles by a previous slug, callers compare the
+		// returned Slug to tell whether the article has moved.
+		GetArticleBySlug(ctx context.Context, slug string) (res *Article, err error)
+		SearchArticles(ctx context.Context, search *ArticleSearch) (res interface{}, err error)
+		// PublishArticle publishes now, or schedules the article when at is in the future.
+		PublishArticle(ctx context.Context, id uuid.UUID, at time.Time) (res interface{}, err error)
+		UnpublishArticle(ctx context.Context, id uuid.UUID) (res interface{}, err error)
+		ArchiveArticle(ctx context.Context, id uuid.UUID) (res interface{}, err error)
+		RestoreArticle(ctx context.Context, id uuid.UUID) error
+		FetchRevisions(ctx context.Context, id uuid.UUID) (res interface{}, err error)
+		// DiffRevisions compares two revisions, a to of 0 means the current content.
+		DiffRevisions(ctx context.Context, id uuid.UUID, from, to int) (res interface{}, err error)
+		// RestoreRevision makes an old revision the current content, itself recorded as a new revision.
+		// Like UpdateArticle it fails with a VersionConflictError unless the article is at version, 0 skips the check.
+		RestoreRevision(ctx context.Context, id uuid.UUID, revision, version int) (res interface{}, err error)
+		PurgeArticle(ctx context.Context, id uuid.UUID) error
+		// RecordView counts a read of the article, once per visitor within the dedup window.
+		RecordView(ctx context.Context, article *Article, visitor string)
+		// PopularArticles ranks articles by views over the last days days, today included.
+		PopularArticles(ctx context.Context, days, limit int) (res interface{}, err error)
+	}
+
+	// ArticleScheduler publishes scheduled articles once they are due.
+	ArticleScheduler interface {
+		// Wake makes the scheduler look at the database again, call it after scheduling an article.
+		Wake()
+	}
+
+	// ArticleViewCounter buffers article views in memory and writes them out in batches.
+	ArticleViewCounter interface {
+		// Record counts a view unless visitor saw the article recently, it never blocks on the database.
+		Record(articleID uuid.UUID, visitor string) bool
 	}
 )
 
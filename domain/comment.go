@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	Comment struct {
+		tableName struct{}  `pg:"comments"`
+		ID        uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		ArticleID uuid.UUID `pg:"article_id,type:uuid" json:"articleId"`
+		ParentID  uuid.UUID `pg:"parent_id,type:uuid" json:"parentId"`
+		// RootID is the top level comment of the thread, it is empty for top level comments.
+		RootID   uuid.UUID `pg:"root_id,type:uuid" json:"rootId"`
+		AuthorID uuid.UUID `pg:"author_id,type:uuid" json:"authorId"`
+		Body     string    `pg:"body" json:"body"`
+		// Depth is 0 for top level comments and one more than the parent for replies.
+		Depth     int         `pg:"depth,use_zero" json:"depth"`
+		HiddenAt  pg.NullTime `pg:"hidden_at" json:"hiddenAt"`
+		HiddenBy  uuid.UUID   `pg:"hidden_by,type:uuid" json:"hiddenBy"`
+		CreatedAt time.Time   `pg:"created_at" json:"createdAt"`
+		UpdatedAt time.Time   `pg:"updated_at" json:"updatedAt"`
+		DeletedAt pg.NullTime `pg:"deleted_at,soft_delete" json:"deletedAt"`
+		Replies   []Comment   `pg:"-" json:"replies"`
+	}
+
+	CommentPage struct {
+		Threads []Comment `json:"threads"`
+		Meta    PageMeta  `json:"meta"`
+	}
+
+	CommentRepository interface {
+		Create(ctx context.Context, comment *Comment) error
+		Find(ctx context.Context, id uuid.UUID) (comment *Comment, err error)
+		UpdateBody(ctx context.Context, id uuid.UUID, body string) error
+		Delete(ctx context.Context, id uuid.UUID) error
+		// SetHidden hides the comment on behalf of moderatorID, or shows it again when hidden is false.
+		SetHidden(ctx context.Context, id uuid.UUID, hidden bool, moderatorID uuid.UUID) error
+		// FetchThreads pages through the top level comments of an article, newest first.
+		// Deleted comments are included so the replies below them keep their place.
+		FetchThreads(ctx context.Context, articleID uuid.UUID, limit, offset int) (res []Comment, total int, err error)
+		// FetchReplies loads the oldest limit replies of each of the given threads, oldest first.
+		FetchReplies(ctx context.Context, rootIDs []uuid.UUID, limit int) (res []Comment, err error)
+	}
+
+	CommentUsecase interface {
+		FetchComments(ctx context.Context, articleID uuid.UUID, limit, offset int) (res interface{}, err error)
+		// StoreComment posts a top level comment, or a reply when ParentID is set.
+		StoreComment(ctx context.Context, comment *Comment) error
+		UpdateComment(ctx context.Context, id uuid.UUID, body string) (res interface{}, err error)
+		DeleteComment(ctx context.Context, id uuid.UUID) error
+		HideComment(ctx context.Context, id uuid.UUID) (res interface{}, err error)
+		UnhideComment(ctx context.Context, id uuid.UUID) (res interface{}, err error)
+	}
+)
@@ -0,0 +1,14 @@
+package domain
+
+// Operations of a DiffLine.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is a single line of a line-level diff.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
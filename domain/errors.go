@@ -0,0 +1,129 @@
+package domain
+
+import "errors"
+
+// The kinds of failure a client is told apart. Every error in this package matches
+// exactly one of them with errors.Is, repositories return ErrNotFound and ErrConflict
+// themselves when nothing more specific applies.
+var (
+	// ErrNotFound is returned when the requested resource does not exist.
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrConflict is returned when a write clashes with what is already stored.
+	ErrConflict = errors.New("resource conflicts with an existing one")
+
+	// ErrUnauthorized is the kind of errors about missing or invalid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden is returned when the authenticated user may not modify the requested resource.
+	ErrForbidden = errors.New("you are not allowed to modify this resource")
+
+	// ErrValidation is the kind of errors about input that can not be accepted.
+	ErrValidation = errors.New("invalid input")
+)
+
+// Error is a failure of one of the kinds above. Code identifies it to clients and
+// stays the same when Message is reworded.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+var (
+	// ErrUnauthenticated is returned when an action needs a signed in user and the request has none.
+	ErrUnauthenticated = &Error{Kind: ErrUnauthorized, Code: "unauthenticated", Message: "authentication required"}
+
+	// ErrInvalidCursor is returned when a pagination cursor can not be decoded or was
+	// issued for a different sort order.
+	ErrInvalidCursor = &Error{Kind: ErrValidation, Code: "invalid_cursor", Message: "cursor is invalid or does not match the requested sort"}
+
+	// ErrInvalidCredentials is returned when logging in with an unknown email or a wrong password.
+	ErrInvalidCredentials = &Error{Kind: ErrUnauthorized, Code: "invalid_credentials", Message: "email or password is incorrect"}
+
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+	ErrInvalidRefreshToken = &Error{Kind: ErrUnauthorized, Code: "invalid_refresh_token", Message: "refresh token is invalid or expired"}
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented
+	// again, the whole session it belongs to is revoked as a precaution.
+	ErrRefreshTokenReused = &Error{Kind: ErrUnauthorized, Code: "refresh_token_reused", Message: "refresh token has already been used, session revoked"}
+
+	// ErrInvalidResetToken is returned when a password reset token is unknown, expired or already used.
+	ErrInvalidResetToken = &Error{Kind: ErrValidation, Code: "invalid_reset_token", Message: "password reset token is invalid or expired"}
+
+	// ErrInvalidVerificationToken is returned when an email verification link is forged,
+	// expired or was issued for an address the user no longer has.
+	ErrInvalidVerificationToken = &Error{Kind: ErrValidation, Code: "invalid_verification_token", Message: "verification link is invalid or expired"}
+
+	// ErrEmailAlreadyVerified is returned when asking for a verification mail for a verified address.
+	ErrEmailAlreadyVerified = &Error{Kind: ErrConflict, Code: "email_already_verified", Message: "email address is already verified"}
+
+	// ErrVerificationCooldown is returned when a verification mail was sent too recently.
+	ErrVerificationCooldown = &Error{Kind: ErrConflict, Code: "verification_cooldown", Message: "a verification mail was sent recently, please wait before asking again"}
+
+	// ErrEmailNotVerified is returned to unverified users when verification is required.
+	ErrEmailNotVerified = &Error{Kind: ErrForbidden, Code: "email_not_verified", Message: "email address is not verified"}
+
+	// ErrEmailTaken is returned when changing to an email address another account already uses.
+	ErrEmailTaken = &Error{Kind: ErrConflict, Code: "email_taken", Message: "email address is already taken"}
+
+	// ErrInvalidPassword is returned when the password confirming a sensitive change is wrong.
+	ErrInvalidPassword = &Error{Kind: ErrValidation, Code: "invalid_password", Message: "password is incorrect"}
+
+	// ErrSlugTaken is returned by repositories when another article claimed the slug first.
+	ErrSlugTaken = &Error{Kind: ErrConflict, Code: "slug_taken", Message: "slug is already taken"}
+
+	// ErrUnknownLanguage is returned for a language PostgreSQL has no text search configuration for.
+	ErrUnknownLanguage = &Error{Kind: ErrValidation, Code: "unknown_language", Message: "unknown search language"}
+
+	// ErrDiffTooLarge is returned when two revisions differ in too many lines to compare.
+	ErrDiffTooLarge = &Error{Kind: ErrValidation, Code: "diff_too_large", Message: "the revisions differ in too many lines to compare"}
+
+	// ErrVersionConflict is returned when an article changed since the version the client last read.
+	ErrVersionConflict = &Error{Kind: ErrConflict, Code: "version_conflict", Message: "article has been modified since it was read"}
+
+	// ErrPreconditionRequired is returned when a write has to name the version it expects and did not.
+	ErrPreconditionRequired = &Error{Kind: ErrValidation, Code: "precondition_required", Message: "the If-Match header is required"}
+
+	// ErrInvalidTag is returned for an empty or overlong tag name or slug, or too many tags on one article.
+	ErrInvalidTag = &Error{Kind: ErrValidation, Code: "invalid_tag", Message: "tags must be 1 to 50 characters long and at most 10 per article"}
+
+	// ErrInvalidParent is returned when replying to a comment that is gone or belongs to another article.
+	ErrInvalidParent = &Error{Kind: ErrValidation, Code: "invalid_parent", Message: "the comment replied to does not exist on this article"}
+
+	// ErrMediaTooLarge is returned for uploads over MEDIA_MAX_SIZE or images with too many pixels.
+	ErrMediaTooLarge = &Error{Kind: ErrValidation, Code: "media_too_large", Message: "file is too large"}
+
+	// ErrUnsupportedMediaType is returned for uploads whose content is not of an allowed type.
+	ErrUnsupportedMediaType = &Error{Kind: ErrValidation, Code: "unsupported_media_type", Message: "file type is not allowed"}
+
+	// ErrInvalidCover is returned when an article cover is not an uploaded image.
+	ErrInvalidCover = &Error{Kind: ErrValidation, Code: "invalid_cover", Message: "cover must be an uploaded image"}
+
+	// ErrUnknownPermission is returned when a role is granted a permission that does not exist.
+	ErrUnknownPermission = &Error{Kind: ErrValidation, Code: "unknown_permission", Message: "unknown permission"}
+
+	// ErrRoleExists is returned when creating a role with a name that is already taken.
+	ErrRoleExists = &Error{Kind: ErrConflict, Code: "role_exists", Message: "a role with this name already exists"}
+)
+
+// VersionConflictError is an ErrVersionConflict that carries the version the article is at now.
+type VersionConflictError struct {
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return ErrVersionConflict.Error()
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
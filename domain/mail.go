@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+type (
+	Mail struct {
+		To      string
+		Subject string
+		Body    string
+	}
+
+	// Mailer delivers transactional mail such as password reset links.
+	Mailer interface {
+		Send(ctx context.Context, mail *Mail) error
+	}
+)
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"io"
+	"strings"
+	"time"
+)
+
+type (
+	// Media is an uploaded file. Path and ThumbnailPath are keys into the MediaStorage,
+	// clients get URLs built from them instead.
+	Media struct {
+		tableName     struct{}  `pg:"media"`
+		ID            uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		OwnerID       uuid.UUID `pg:"owner_id,type:uuid" json:"ownerId"`
+		Path          string    `pg:"path,type:varchar(255)" json:"-"`
+		ThumbnailPath string    `pg:"thumbnail_path,type:varchar(255)" json:"-"`
+		Filename      string    `pg:"filename,type:varchar(255)" json:"filename"`
+		MimeType      string    `pg:"mime_type,type:varchar(100)" json:"mimeType"`
+		Size          int64     `pg:"size,use_zero" json:"size"`
+		Width         int       `pg:"width" json:"width,omitempty"`
+		Height        int       `pg:"height" json:"height,omitempty"`
+		CreatedAt     time.Time `pg:"created_at" json:"createdAt"`
+		UpdatedAt     time.Time `pg:"updated_at" json:"updatedAt"`
+		URL           string    `pg:"-" json:"url"`
+		ThumbnailURL  string    `pg:"-" json:"thumbnailUrl,omitempty"`
+	}
+
+	// MediaUpload is a file as received from the client, nothing about it is trusted.
+	MediaUpload struct {
+		Filename string
+		Size     int64
+		Content  io.Reader
+	}
+
+	// MediaStorage keeps the bytes of uploaded files, on the local disk or in an S3 bucket.
+	MediaStorage interface {
+		Put(ctx context.Context, path string, content []byte, contentType string) error
+		// Delete removes path, a path that does not exist is not an error.
+		Delete(ctx context.Context, path string) error
+		// URL is where clients can download path from.
+		URL(path string) string
+	}
+
+	MediaRepository interface {
+		Create(ctx context.Context, media *Media) error
+		Find(ctx context.Context, id uuid.UUID) (media *Media, err error)
+		FindMany(ctx context.Context, ids []uuid.UUID) (res []Media, err error)
+		Delete(ctx context.Context, id uuid.UUID) error
+	}
+
+	MediaUsecase interface {
+		Upload(ctx context.Context, upload *MediaUpload) (res *Media, err error)
+		Find(ctx context.Context, id uuid.UUID) (res *Media, err error)
+		// FindMany loads several media at once, keyed by id. Unknown ids are left out.
+		FindMany(ctx context.Context, ids []uuid.UUID) (res map[uuid.UUID]*Media, err error)
+		Delete(ctx context.Context, id uuid.UUID) error
+	}
+)
+
+// IsImage tells whether the media is a picture thumbnails were made for.
+func (m *Media) IsImage() bool {
+	return strings.HasPrefix(m.MimeType, "image/")
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// PasswordReset is a pending reset request, Token holds the sha256 of the token that was mailed.
+	PasswordReset struct {
+		tableName struct{}  `pg:"password_resets"`
+		Email     string    `pg:"email,type:varchar(255)"`
+		Token     string    `pg:"token,type:varchar(255)"`
+		CreatedAt time.Time `pg:"created_at"`
+	}
+
+	PasswordResetRepository interface {
+		Create(ctx context.Context, reset *PasswordReset) error
+		FindByEmail(ctx context.Context, email string) (reset *PasswordReset, err error)
+		DeleteByEmail(ctx context.Context, email string) error
+	}
+)
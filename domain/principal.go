@@ -0,0 +1,21 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Principal is the authenticated caller of a request as described by its access token.
+type Principal struct {
+	UserID      uuid.UUID
+	TokenID     uuid.UUID
+	Roles       []string
+	Permissions []string
+}
+
+// Can reports whether the principal has been granted permission.
+func (p *Principal) Can(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
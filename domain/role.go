@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Permissions checked by the application, the admin role is seeded with all of them.
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersDelete      = "users:delete"
+	PermissionArticlesUpdate   = "articles:update"
+	PermissionArticlesDelete   = "articles:delete"
+	PermissionRolesManage      = "roles:manage"
+	PermissionCommentsModerate = "comments:moderate"
+	PermissionMediaManage      = "media:manage"
+)
+
+type (
+	Role struct {
+		tableName   struct{}  `pg:"roles"`
+		ID          uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		Name        string    `pg:"name,type:varchar(255)" json:"name"`
+		Permissions []string  `pg:"permissions,array" json:"permissions"`
+		CreatedAt   time.Time `pg:"created_at" json:"createdAt"`
+		UpdatedAt   time.Time `pg:"updated_at" json:"updatedAt"`
+	}
+
+	Permission struct {
+		tableName   struct{}  `pg:"permissions"`
+		ID          uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		Name        string    `pg:"name,type:varchar(255)" json:"name"`
+		Description string    `pg:"description,type:varchar(255)" json:"description"`
+	}
+
+	RoleRepository interface {
+		Fetch(ctx context.Context) (res []Role, err error)
+		Find(ctx context.Context, id uuid.UUID) (role *Role, err error)
+		// Create stores the role together with the permissions it grants.
+		Create(ctx context.Context, role *Role) error
+		Delete(ctx context.Context, id uuid.UUID) error
+		SetPermissions(ctx context.Context, id uuid.UUID, permissions []string) error
+		FetchPermissions(ctx context.Context) (res []Permission, err error)
+		AssignUser(ctx context.Context, id, userID uuid.UUID) error
+		UnassignUser(ctx context.Context, id, userID uuid.UUID) error
+		// FindByUser returns the names of the user's roles and of every permission they grant.
+		FindByUser(ctx context.Context, userID uuid.UUID) (roles, permissions []string, err error)
+	}
+
+	RoleUsecase interface {
+		Fetch(ctx context.Context) (res interface{}, err error)
+		FetchPermissions(ctx context.Context) (res interface{}, err error)
+		Store(ctx context.Context, role *Role) error
+		Destroy(ctx context.Context, id uuid.UUID) error
+		UpdatePermissions(ctx context.Context, id uuid.UUID, permissions []string) (res interface{}, err error)
+		AssignUser(ctx context.Context, id, userID uuid.UUID) error
+		UnassignUser(ctx context.Context, id, userID uuid.UUID) error
+	}
+)
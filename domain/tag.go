@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	Tag struct {
+		tableName struct{}  `pg:"tags"`
+		ID        uuid.UUID `pg:"id,pk,type:uuid" json:"id"`
+		Name      string    `pg:"name,type:varchar(50)" json:"name"`
+		Slug      string    `pg:"slug,type:varchar(60)" json:"slug"`
+		CreatedAt time.Time `pg:"created_at" json:"createdAt"`
+		UpdatedAt time.Time `pg:"updated_at" json:"updatedAt"`
+	}
+
+	// TagCount is a tag with the number of published articles carrying it.
+	TagCount struct {
+		Tag
+		Articles int `pg:"articles" json:"articles"`
+	}
+
+	ArticleTag struct {
+		tableName struct{}  `pg:"article_tags"`
+		ArticleID uuid.UUID `pg:"article_id,pk,type:uuid"`
+		TagID     uuid.UUID `pg:"tag_id,pk,type:uuid"`
+	}
+
+	TagRepository interface {
+		Fetch(ctx context.Context) (res []TagCount, err error)
+		FindBySlug(ctx context.Context, slug string) (tag *Tag, err error)
+		// Ensure creates the tags whose slug does not exist yet and returns all of them.
+		Ensure(ctx context.Context, tags []Tag) (res []Tag, err error)
+		// SetArticleTags replaces the tags of an article.
+		SetArticleTags(ctx context.Context, articleID uuid.UUID, tagIDs []uuid.UUID) error
+		// FetchByArticles loads the tags of several articles in one query, keyed by article.
+		FetchByArticles(ctx context.Context, articleIDs []uuid.UUID) (res map[uuid.UUID][]Tag, err error)
+	}
+
+	TagUsecase interface {
+		FetchTags(ctx context.Context) (res interface{}, err error)
+		// FetchArticles lists the published articles tagged slug.
+		FetchArticles(ctx context.Context, slug string, filter *ArticleFilter) (res interface{}, err error)
+	}
+)
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	// RefreshToken is an opaque, single use token that can be traded for a new access token.
+	// Every token issued from the same login shares a FamilyID, which is what gets revoked
+	// on logout or when an already used token is replayed.
+	RefreshToken struct {
+		tableName     struct{}    `pg:"refresh_tokens"`
+		ID            uuid.UUID   `pg:"id,pk,type:uuid"`
+		UserID        uuid.UUID   `pg:"user_id,type:uuid"`
+		FamilyID      uuid.UUID   `pg:"family_id,type:uuid"`
+		TokenHash     string      `pg:"token_hash,type:varchar(64)"`
+		AccessTokenID uuid.UUID   `pg:"access_token_id,type:uuid"`
+		ExpiresAt     time.Time   `pg:"expires_at"`
+		UsedAt        pg.NullTime `pg:"used_at"`
+		RevokedAt     pg.NullTime `pg:"revoked_at"`
+		CreatedAt     time.Time   `pg:"created_at"`
+	}
+
+	RefreshTokenRepository interface {
+		Create(ctx context.Context, token *RefreshToken) error
+		FindByHash(ctx context.Context, hash string) (token *RefreshToken, err error)
+		// MarkUsed flags the token as rotated and reports false when it had already been used.
+		MarkUsed(ctx context.Context, id uuid.UUID) (ok bool, err error)
+		RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+		RevokeUser(ctx context.Context, userID uuid.UUID) error
+		// RevokeOtherSessions ends every session of the user except the one accessTokenID belongs to.
+		RevokeOtherSessions(ctx context.Context, userID, accessTokenID uuid.UUID) error
+		RevokeByAccessToken(ctx context.Context, accessTokenID uuid.UUID) error
+		IsAccessTokenRevoked(ctx context.Context, accessTokenID uuid.UUID) (revoked bool, err error)
+	}
+)
@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// Transactor runs fn in a transaction. The repository calls fn makes with the context it
+// is given are committed together, or rolled back when fn returns an error.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 	"time"
 )
@@ -20,8 +21,17 @@ type (
 		Name      string    `pg:"name,type:varchar(255)" json:"name" form:"name" validate:"required"`
 		Email     string    `pg:"email,type:varchar(255)" json:"email" form:"email" validate:"required,email"`
 		Password  string    `pg:"password,type:varchar(255)" json:"-" form:"password" validate:"required"`
-		CreatedAt time.Time `pg:"created_at" json:"createdAt"`
-		UpdatedAt time.Time `pg:"updated_at" json:"updatedAt"`
+
+		// Roles and Permissions are loaded from the role tables when tokens are issued.
+		Roles       []string `pg:"-" json:"roles,omitempty"`
+		Permissions []string `pg:"-" json:"permissions,omitempty"`
+
+		EmailVerifiedAt         pg.NullTime `pg:"email_verified_at" json:"emailVerifiedAt"`
+		EmailVerificationSentAt pg.NullTime `pg:"email_verification_sent_at" json:"-"`
+
+		CreatedAt time.Time   `pg:"created_at" json:"createdAt"`
+		UpdatedAt time.Time   `pg:"updated_at" json:"updatedAt"`
+		DeletedAt pg.NullTime `pg:"deleted_at,soft_delete" json:"deletedAt"`
 	}
 )
 
@@ -30,6 +40,13 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, usr *User) error
 	Attempt(ctx context.Context, credential *Credential) (user *User, err error)
 	Update(ctx context.Context, usr *User) error
+	// ChangeEmail switches the user to email and marks the new address as unverified.
+	ChangeEmail(ctx context.Context, id uuid.UUID, email string) error
+	// EmailTaken reports whether an account other than exceptID, trashed ones included, uses email.
+	EmailTaken(ctx context.Context, email string, exceptID uuid.UUID) (bool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) error
+	ForceDelete(ctx context.Context, id uuid.UUID) error
 	Find(ctx context.Context, id uuid.UUID) (user *User, err error)
 	FindBy(ctx context.Context, key, value string) (user *User, err error)
 	Fetch(ctx context.Context, limit, offset int) (res []User, err error)
@@ -39,5 +56,18 @@ type UserRepository interface {
 type UserUseCase interface {
 	Register(ctx context.Context, usr *User) error
 	Login(ctx context.Context, credential *Credential) (res interface{}, err error)
+	RefreshToken(ctx context.Context, token string) (res interface{}, err error)
+	Logout(ctx context.Context) error
+	Profile(ctx context.Context) (res interface{}, err error)
+	UpdateProfile(ctx context.Context, name, email string) (res interface{}, err error)
+	ChangePassword(ctx context.Context, current, password string) error
+	DeleteAccount(ctx context.Context, password string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, email, token, password string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context) error
 	Fetch(ctx context.Context, limit, offset int) (res interface{}, err error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RestoreUser(ctx context.Context, id uuid.UUID) error
+	PurgeUser(ctx context.Context, id uuid.UUID) error
 }
@@ -1,278 +0,0 @@
---
--- PostgreSQL database dump
---
-
--- Dumped from database version 13.1
--- Dumped by pg_dump version 13.1
-
-SET statement_timeout = 0;
-SET lock_timeout = 0;
-SET idle_in_transaction_session_timeout = 0;
-SET client_encoding = 'UTF8';
-SET standard_conforming_strings = on;
-SELECT pg_catalog.set_config('search_path', '', false);
-SET check_function_bodies = false;
-SET xmloption = content;
-SET client_min_messages = warning;
-SET row_security = off;
-
-SET default_tablespace = '';
-
-SET default_table_access_method = heap;
-
---
--- Name: articles; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.articles (
-    id uuid NOT NULL,
-    title character varying(255) NOT NULL,
-    slug character varying(255) NOT NULL,
-    description text NOT NULL,
-    deleted_at timestamp(0) without time zone,
-    created_at timestamp(0) without time zone,
-    updated_at timestamp(0) without time zone
-);
-
-
-ALTER TABLE public.articles OWNER TO postgres;
-
---
--- Name: failed_jobs; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.failed_jobs (
-    id bigint NOT NULL,
-    uuid character varying(255) NOT NULL,
-    connection text NOT NULL,
-    queue text NOT NULL,
-    payload text NOT NULL,
-    exception text NOT NULL,
-    failed_at timestamp(0) without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
-);
-
-
-ALTER TABLE public.failed_jobs OWNER TO postgres;
-
---
--- Name: failed_jobs_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.failed_jobs_id_seq
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER TABLE public.failed_jobs_id_seq OWNER TO postgres;
-
---
--- Name: failed_jobs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.failed_jobs_id_seq OWNED BY public.failed_jobs.id;
-
-
---
--- Name: migrations; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.migrations (
-    id integer NOT NULL,
-    migration character varying(255) NOT NULL,
-    batch integer NOT NULL
-);
-
-
-ALTER TABLE public.migrations OWNER TO postgres;
-
---
--- Name: migrations_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.migrations_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER TABLE public.migrations_id_seq OWNER TO postgres;
-
---
--- Name: migrations_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.migrations_id_seq OWNED BY public.migrations.id;
-
-
---
--- Name: password_resets; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.password_resets (
-    email character varying(255) NOT NULL,
-    token character varying(255) NOT NULL,
-    created_at timestamp(0) without time zone
-);
-
-
-ALTER TABLE public.password_resets OWNER TO postgres;
-
---
--- Name: users; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.users (
-    id uuid NOT NULL,
-    name character varying(255) NOT NULL,
-    email character varying(255) NOT NULL,
-    email_verified_at timestamp(0) without time zone,
-    password character varying(255) NOT NULL,
-    remember_token character varying(100),
-    created_at timestamp(0) without time zone,
-    updated_at timestamp(0) without time zone,
-    deleted_at timestamp(0) without time zone
-);
-
-
-ALTER TABLE public.users OWNER TO postgres;
-
---
--- Name: failed_jobs id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.failed_jobs ALTER COLUMN id SET DEFAULT nextval('public.failed_jobs_id_seq'::regclass);
-
-
---
--- Name: migrations id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.migrations ALTER COLUMN id SET DEFAULT nextval('public.migrations_id_seq'::regclass);
-
-
---
--- Data for Name: articles; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.articles (id, title, slug, description, deleted_at, created_at, updated_at) FROM stdin;
-5c270ab2-a36f-4a1a-8182-7c657529bfd9	Lorem IP sum	Lorem-IP-sum	If you are using this in a query, the QueryEscape method provided by net/url is the best solution: https://golang.org/pkg/net/url/#QueryEscape	\N	2021-02-14 16:51:24	2021-02-14 16:51:24
-\.
-
-
---
--- Data for Name: failed_jobs; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.failed_jobs (id, uuid, connection, queue, payload, exception, failed_at) FROM stdin;
-\.
-
-
---
--- Data for Name: migrations; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.migrations (id, migration, batch) FROM stdin;
-1	2014_10_12_000000_create_users_table	1
-2	2014_10_12_100000_create_password_resets_table	1
-3	2019_08_19_000000_create_failed_jobs_table	1
-4	2021_02_14_111944_create_articles_table	1
-\.
-
-
---
--- Data for Name: password_resets; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.password_resets (email, token, created_at) FROM stdin;
-\.
-
-
---
--- Data for Name: users; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.users (id, name, email, email_verified_at, password, remember_token, created_at, updated_at, deleted_at) FROM stdin;
-913c8628-d9f8-312a-89c7-0abc3484b16a	Prof. Ericka Walter	vharber@example.com	2021-02-14 16:51:15	$2y$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi	YUPdrs262n	2021-02-14 16:51:15	2021-02-14 16:51:15	\N
-299a7d91-5c9f-4be3-8cc4-01c012f4d3b9	Admin 	admin@example.com	\N	$2a$10$4FDC48MBCxoiv6mAzghZG.IlU3zXsLH4ieANMGfuTfKY99/vYX9wO	\N	2021-02-15 15:29:45	2021-02-15 15:29:45	\N
-\.
-
-
---
--- Name: failed_jobs_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.failed_jobs_id_seq', 1, false);
-
-
---
--- Name: migrations_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.migrations_id_seq', 4, true);
-
-
---
--- Name: articles articles_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.articles
-    ADD CONSTRAINT articles_pkey PRIMARY KEY (id);
-
-
---
--- Name: failed_jobs failed_jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.failed_jobs
-    ADD CONSTRAINT failed_jobs_pkey PRIMARY KEY (id);
-
-
---
--- Name: failed_jobs failed_jobs_uuid_unique; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.failed_jobs
-    ADD CONSTRAINT failed_jobs_uuid_unique UNIQUE (uuid);
-
-
---
--- Name: migrations migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.migrations
-    ADD CONSTRAINT migrations_pkey PRIMARY KEY (id);
-
-
---
--- Name: users users_email_unique; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_email_unique UNIQUE (email);
-
-
---
--- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
-
-
---
--- Name: password_resets_email_index; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX password_resets_email_index ON public.password_resets USING btree (email);
-
-
---
--- PostgreSQL database dump complete
---
-
@@ -0,0 +1,32 @@
+package helper
+
+import "github.com/spf13/viper"
+
+const (
+	defaultCommentMaxDepth   = 3
+	defaultCommentMaxReplies = 100
+)
+
+// CommentMaxDepth is how many levels of replies a comment thread may have, at least one.
+func CommentMaxDepth() int {
+	depth := viper.GetInt("COMMENT_MAX_DEPTH")
+	if !viper.IsSet("COMMENT_MAX_DEPTH") {
+		depth = defaultCommentMaxDepth
+	}
+	if depth < 1 {
+		return 1
+	}
+	return depth
+}
+
+// CommentMaxReplies is how many replies of each thread are listed with an article, at least one.
+func CommentMaxReplies() int {
+	replies := viper.GetInt("COMMENT_MAX_REPLIES")
+	if !viper.IsSet("COMMENT_MAX_REPLIES") {
+		replies = defaultCommentMaxReplies
+	}
+	if replies < 1 {
+		return 1
+	}
+	return replies
+}
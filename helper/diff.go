@@ -0,0 +1,91 @@
+package helper
+
+import (
+	"go-boilerplate/domain"
+	"strings"
+)
+
+// maxDiffCells bounds the table DiffLines fills, one cell for every pair of lines left
+// once the lines both texts start and end with are cut off.
+const maxDiffCells = 4 << 20
+
+// DiffLines returns the line-level diff turning a into b, based on their longest
+// common subsequence of lines. Texts too different to compare return
+// domain.ErrDiffTooLarge.
+func DiffLines(a, b string) ([]domain.DiffLine, error) {
+	from, to := splitLines(a), splitLines(b)
+
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	middleFrom, middleTo := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+	if (len(middleFrom)+1)*(len(middleTo)+1) > maxDiffCells {
+		return nil, domain.ErrDiffTooLarge
+	}
+
+	diff := make([]domain.DiffLine, 0, len(from)+len(to))
+	for _, line := range from[:prefix] {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: line})
+	}
+	diff = append(diff, diffLCS(middleFrom, middleTo)...)
+	for _, line := range from[len(from)-suffix:] {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: line})
+	}
+	return diff, nil
+}
+
+func diffLCS(from, to []string) []domain.DiffLine {
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]domain.DiffLine, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: from[i]})
+			i++
+		default:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: to[j]})
+	}
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
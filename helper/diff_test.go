@@ -0,0 +1,66 @@
+package helper_test
+
+import (
+	"errors"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffEqual, Text: text} }
+	ins := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffInsert, Text: text} }
+	del := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffDelete, Text: text} }
+
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.DiffLine
+	}{
+		{"both empty", "", "", []domain.DiffLine{}},
+		{"unchanged", "a\nb", "a\nb", []domain.DiffLine{eq("a"), eq("b")}},
+		{"added to empty", "", "a\nb", []domain.DiffLine{ins("a"), ins("b")}},
+		{"emptied", "a\nb", "", []domain.DiffLine{del("a"), del("b")}},
+		{"line changed in the middle", "a\nb\nc", "a\nx\nc", []domain.DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"line moved", "a\nb\nc", "b\nc\na", []domain.DiffLine{del("a"), eq("b"), eq("c"), ins("a")}},
+		{"windows line endings", "a\r\nb", "a\nb\nc", []domain.DiffLine{eq("a"), eq("b"), ins("c")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := helper.DiffLines(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesOfLongTexts(t *testing.T) {
+	lines := func(prefix string, n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(prefix + strings.Repeat("x", i%7) + "\n")
+		}
+		return b.String()
+	}
+
+	// Long texts sharing their start and end only compare the lines in between.
+	shared := lines("same", 20000)
+	diff, err := helper.DiffLines(shared+"old\n"+shared, shared+"new\n"+shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 40003 {
+		t.Errorf("got %d lines, want 40003", len(diff))
+	}
+
+	if _, err := helper.DiffLines(lines("old", 20000), lines("new", 20000)); !errors.Is(err, domain.ErrDiffTooLarge) {
+		t.Errorf("err = %v, want %v", err, domain.ErrDiffTooLarge)
+	}
+}
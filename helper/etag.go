@@ -0,0 +1,34 @@
+package helper
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+	"strconv"
+	"strings"
+)
+
+// ETag returns the entity tag of a resource at version. Variants tell apart the
+// representations of one version, e.g. its formats.
+func ETag(version int, variants ...string) string {
+	return `"` + strings.Join(append([]string{strconv.Itoa(version)}, variants...), "-") + `"`
+}
+
+// ParseETag returns the version an ETag header value refers to, weak tags and tags
+// of any variant are accepted.
+func ParseETag(tag string) (int, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, errors.New("malformed entity tag")
+	}
+	version := strings.SplitN(tag[1:len(tag)-1], "-", 2)[0]
+	return strconv.Atoi(version)
+}
+
+// IfMatchRequired reports whether article writes must name the version they expect,
+// ARTICLE_REQUIRE_IF_MATCH defaults to true.
+func IfMatchRequired() bool {
+	if !viper.IsSet("ARTICLE_REQUIRE_IF_MATCH") {
+		return true
+	}
+	return viper.GetBool("ARTICLE_REQUIRE_IF_MATCH")
+}
@@ -0,0 +1,32 @@
+package helper_test
+
+import (
+	"go-boilerplate/helper"
+	"testing"
+)
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    int
+		wantErr bool
+	}{
+		{"strong", helper.ETag(3), 3, false},
+		{"variant", helper.ETag(4, "html"), 4, false},
+		{"weak", `W/"12"`, 12, false},
+		{"surrounding space", ` "7" `, 7, false},
+		{"unquoted", "7", 0, true},
+		{"not a version", `"abc"`, 0, true},
+		{"empty", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := helper.ParseETag(tt.tag)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseETag(%q) = %d, %v, want %d", tt.tag, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"net"
+)
+
+// IPExtractor tells echo where client addresses come from. Requests passing through
+// one of the TRUSTED_PROXIES, a list of CIDR ranges, are attributed to the address in
+// X-Forwarded-For, any other request to the address it was sent from.
+func IPExtractor() (echo.IPExtractor, error) {
+	proxies := viper.GetStringSlice("TRUSTED_PROXIES")
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	// Echo trusts private networks by default, only the configured ranges are.
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
@@ -0,0 +1,51 @@
+package helper_test
+
+import (
+	"github.com/spf13/viper"
+	"go-boilerplate/helper"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "no proxies", remoteAddr: "203.0.113.7:4711", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "private peer without proxies", remoteAddr: "10.0.0.2:4711", forwarded: "198.51.100.1", want: "10.0.0.2"},
+		{name: "trusted proxy", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.2:4711",
+			forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "spoofed hop before the proxy", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.2:4711",
+			forwarded: "192.0.2.9, 198.51.100.1", want: "198.51.100.1"},
+		{name: "untrusted peer", proxies: []string{"10.0.0.0/8"}, remoteAddr: "203.0.113.7:4711",
+			forwarded: "198.51.100.1", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("TRUSTED_PROXIES", tt.proxies)
+			defer viper.Set("TRUSTED_PROXIES", nil)
+
+			extract, err := helper.IPExtractor()
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+			if got := extract(req); got != tt.want {
+				t.Errorf("IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	viper.Set("TRUSTED_PROXIES", []string{"10.0.0.0"})
+	defer viper.Set("TRUSTED_PROXIES", nil)
+	if _, err := helper.IPExtractor(); err == nil {
+		t.Error("an address without a prefix length was accepted")
+	}
+}
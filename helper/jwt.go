@@ -14,10 +14,12 @@ import (
 
 type Claims struct {
 	jwt.StandardClaims
-	User *domain.User `json:"user"`
+	User        *domain.User `json:"user"`
+	Roles       []string     `json:"roles"`
+	Permissions []string     `json:"permissions"`
 }
 
-func GenerateJwt(ctx context.Context, user *domain.User) (token string, exp int64, err error) {
+func GenerateJwt(ctx context.Context, user *domain.User) (token string, jti uuid.UUID, exp int64, err error) {
 	secret := viper.GetString("JWT_SECRET")
 	tkExp := viper.GetDuration("JWT_EXPIRED_TOKEN_DURATION") * time.Minute
 	JwtIssuer := viper.GetString("JWT_ISSUER")
@@ -32,7 +34,9 @@ func GenerateJwt(ctx context.Context, user *domain.User) (token string, exp int6
 			ExpiresAt: time.Now().Add(tkExp).Unix(),
 			Subject:   user.ID.String(),
 		},
-		User: user,
+		User:        user,
+		Roles:       user.Roles,
+		Permissions: user.Permissions,
 	}
 
 	tokenClaims := jwt.NewWithClaims(
@@ -42,10 +46,10 @@ func GenerateJwt(ctx context.Context, user *domain.User) (token string, exp int6
 
 	token, err = tokenClaims.SignedString([]byte(secret))
 	if err != nil {
-		return "", 0, err
+		return "", uuid.Nil, 0, err
 	}
 
-	return token, claims.ExpiresAt, nil
+	return token, jwtid, claims.ExpiresAt, nil
 
 }
 
@@ -77,3 +81,40 @@ func ParseToken(c echo.Context) (jwt.MapClaims, error) {
 
 	return claims, err
 }
+
+// ParsePrincipal verifies an access token and returns the principal it was issued to.
+func ParsePrincipal(tokenString string) (*domain.Principal, error) {
+	claims := new(Claims)
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if jwt.SigningMethodHS256 != token.Method {
+			return nil, errors.New("invalid token")
+		}
+
+		return []byte(viper.GetString("JWT_SECRET")), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	tokenID, err := uuid.Parse(claims.Id)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	return &domain.Principal{
+		UserID:      userID,
+		TokenID:     tokenID,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"github.com/spf13/viper"
+	"strings"
+)
+
+const defaultMediaMaxSize = 10 << 20
+
+var defaultMediaTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"}
+
+// MediaMaxSize is the largest upload accepted in bytes, MEDIA_MAX_SIZE.
+func MediaMaxSize() int64 {
+	if size := viper.GetInt64("MEDIA_MAX_SIZE"); size > 0 {
+		return size
+	}
+	return defaultMediaMaxSize
+}
+
+// MediaAllowedTypes are the MIME types uploads may have, MEDIA_ALLOWED_TYPES is a comma
+// separated list.
+func MediaAllowedTypes() []string {
+	var types []string
+	for _, t := range strings.Split(viper.GetString("MEDIA_ALLOWED_TYPES"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, strings.ToLower(t))
+		}
+	}
+	if len(types) == 0 {
+		return defaultMediaTypes
+	}
+	return types
+}
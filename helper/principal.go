@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"go-boilerplate/domain"
+)
+
+// principalKey is the key the authenticated principal is stored under, in the echo
+// context as well as the request context.
+const principalKey = "principal"
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal *domain.Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx by the Auth middleware.
+func PrincipalFromContext(ctx context.Context) (*domain.Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*domain.Principal)
+	return principal, ok && principal != nil
+}
+
+// RequirePrincipal is PrincipalFromContext for code paths that need an authenticated caller.
+func RequirePrincipal(ctx context.Context) (*domain.Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthenticated
+	}
+	return principal, nil
+}
+
+// SetPrincipal stores principal in the echo context and in the context of its request.
+func SetPrincipal(c echo.Context, principal *domain.Principal) {
+	c.Set(principalKey, principal)
+	c.SetRequest(c.Request().WithContext(WithPrincipal(c.Request().Context(), principal)))
+}
+
+// Principal returns the principal the Auth middleware stored in the echo context.
+func Principal(c echo.Context) (*domain.Principal, bool) {
+	principal, ok := c.Get(principalKey).(*domain.Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,14 @@
+package helper
+
+import "github.com/spf13/viper"
+
+const defaultSearchLanguage = "english"
+
+// SearchLanguage is the PostgreSQL text search configuration used for articles
+// that do not name one, e.g. english, indonesian or simple.
+func SearchLanguage() string {
+	if language := viper.GetString("SEARCH_LANGUAGE"); language != "" {
+		return language
+	}
+	return defaultSearchLanguage
+}
@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/spf13/viper"
+	"time"
+)
+
+const (
+	defaultRefreshTokenDuration  = 30 * 24 * time.Hour
+	defaultPasswordResetDuration = time.Hour
+)
+
+// GenerateOpaqueToken returns a random url safe token together with the hash that
+// should be persisted in its place.
+func GenerateOpaqueToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex encoded sha256 of an opaque token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTokenDuration is how long a refresh token stays valid, JWT_REFRESH_TOKEN_DURATION is in minutes.
+func RefreshTokenDuration() time.Duration {
+	if minutes := viper.GetDuration("JWT_REFRESH_TOKEN_DURATION"); minutes > 0 {
+		return minutes * time.Minute
+	}
+	return defaultRefreshTokenDuration
+}
+
+// PasswordResetDuration is how long a password reset token stays valid, PASSWORD_RESET_EXPIRE is in minutes.
+func PasswordResetDuration() time.Duration {
+	if minutes := viper.GetDuration("PASSWORD_RESET_EXPIRE"); minutes > 0 {
+		return minutes * time.Minute
+	}
+	return defaultPasswordResetDuration
+}
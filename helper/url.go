@@ -0,0 +1,16 @@
+package helper
+
+import (
+	"github.com/spf13/viper"
+	"net/url"
+	"strings"
+)
+
+// AppURL builds an absolute link to path on APP_URL, used for links sent by mail.
+func AppURL(path string, query url.Values) string {
+	link := strings.TrimRight(viper.GetString("APP_URL"), "/") + "/" + strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+	"time"
+)
+
+const (
+	verificationAudience        = "email-verification"
+	defaultVerificationDuration = 24 * time.Hour
+	defaultVerificationCooldown = time.Minute
+)
+
+type verificationClaims struct {
+	jwt.StandardClaims
+	Email string `json:"email"`
+}
+
+// GenerateVerificationToken signs a token proving the holder received mail at the user's
+// current address. Changing the address invalidates every token issued before.
+func GenerateVerificationToken(user *domain.User) (string, error) {
+	claims := verificationClaims{
+		StandardClaims: jwt.StandardClaims{
+			Audience:  verificationAudience,
+			Issuer:    viper.GetString("JWT_ISSUER"),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(VerificationDuration()).Unix(),
+			Subject:   user.ID.String(),
+		},
+		Email: user.Email,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(viper.GetString("JWT_SECRET")))
+}
+
+// ParseVerificationToken returns the user id and address a verification token was issued for.
+func ParseVerificationToken(token string) (userID uuid.UUID, email string, err error) {
+	claims := new(verificationClaims)
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if jwt.SigningMethodHS256 != token.Method {
+			return nil, errors.New("invalid token")
+		}
+
+		return []byte(viper.GetString("JWT_SECRET")), nil
+	})
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if !claims.VerifyAudience(verificationAudience, true) {
+		return uuid.Nil, "", errors.New("invalid token")
+	}
+
+	userID, err = uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return userID, claims.Email, nil
+}
+
+// VerificationDuration is how long a verification link stays valid, EMAIL_VERIFICATION_EXPIRE is in minutes.
+func VerificationDuration() time.Duration {
+	if minutes := viper.GetDuration("EMAIL_VERIFICATION_EXPIRE"); minutes > 0 {
+		return minutes * time.Minute
+	}
+	return defaultVerificationDuration
+}
+
+// VerificationCooldown is how long a user has to wait before asking for another verification
+// mail, EMAIL_VERIFICATION_RESEND_COOLDOWN is in seconds.
+func VerificationCooldown() time.Duration {
+	if seconds := viper.GetDuration("EMAIL_VERIFICATION_RESEND_COOLDOWN"); seconds > 0 {
+		return seconds * time.Second
+	}
+	return defaultVerificationCooldown
+}
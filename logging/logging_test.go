@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	t.Cleanup(func() { logrus.SetOutput(logrus.New().Out) })
+
+	FromContext(WithRequestID(context.Background(), "req-1")).Warnln("inside a request")
+	FromContext(context.Background()).Warnln("outside of requests")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], "request_id=req-1") {
+		t.Errorf("request id missing: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("unexpected request id: %s", lines[1])
+	}
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"go-boilerplate/domain"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	Dir  string
+	From string
+	mu   sync.Mutex
+	seq  int
+}
+
+// NewFileMailer returns a Mailer that writes every message to its own file in dir,
+// handy for local development and for asserting on sent mail in tests.
+func NewFileMailer(dir, from string) domain.Mailer {
+	return &fileMailer{Dir: dir, From: from}
+}
+
+func (f *fileMailer) Send(ctx context.Context, mail *domain.Mail) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+
+	f.seq++
+	now := time.Now()
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.Format("20060102150405"), f.seq, sanitize(mail.To))
+
+	content := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		f.From, mail.To, mail.Subject, now.Format(time.RFC1123Z), mail.Body)
+
+	return os.WriteFile(filepath.Join(f.Dir, name), []byte(content), 0644)
+}
+
+func sanitize(address string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, address)
+}
@@ -0,0 +1,23 @@
+package mail
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+)
+
+type logMailer struct{}
+
+// NewLogMailer returns a Mailer that only writes outgoing mail to the log, for local development.
+func NewLogMailer() domain.Mailer {
+	return logMailer{}
+}
+
+func (l logMailer) Send(ctx context.Context, mail *domain.Mail) error {
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"to":      mail.To,
+		"subject": mail.Subject,
+	}).Infoln(mail.Body)
+	return nil
+}
@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+)
+
+// NewMailer picks the Mailer configured by MAIL_DRIVER, "file" or "log" (the default).
+func NewMailer() domain.Mailer {
+	switch viper.GetString("MAIL_DRIVER") {
+	case "file":
+		dir := viper.GetString("MAIL_FILE_PATH")
+		if dir == "" {
+			dir = "storage/mail"
+		}
+		return NewFileMailer(dir, viper.GetString("MAIL_FROM"))
+	default:
+		return NewLogMailer()
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"go-boilerplate/db/postgresql"
+	"go-boilerplate/helper"
+	"go-boilerplate/mail"
 	MiddlewareCustom "go-boilerplate/middleware"
 	"net/http"
 	"os"
@@ -16,7 +18,22 @@ import (
 
 	_articleHttpDelivery "go-boilerplate/article/delivery/http"
 	_articlePostgreRepository "go-boilerplate/article/repository/postgresql"
+	_articleScheduler "go-boilerplate/article/scheduler"
 	_articleUsecase "go-boilerplate/article/usecase"
+	_articleViews "go-boilerplate/article/views"
+	_commentHttpDelivery "go-boilerplate/comment/delivery/http"
+	_commentPostgreRepository "go-boilerplate/comment/repository/postgresql"
+	_commentUsecase "go-boilerplate/comment/usecase"
+	_mediaHttpDelivery "go-boilerplate/media/delivery/http"
+	_mediaPostgreRepository "go-boilerplate/media/repository/postgresql"
+	_mediaStorage "go-boilerplate/media/storage"
+	_mediaUsecase "go-boilerplate/media/usecase"
+	_roleHttpDelivery "go-boilerplate/role/delivery/http"
+	_rolePostgreRepository "go-boilerplate/role/repository/postgresql"
+	_roleUsecase "go-boilerplate/role/usecase"
+	_tagHttpDelivery "go-boilerplate/tag/delivery/http"
+	_tagPostgreRepository "go-boilerplate/tag/repository/postgresql"
+	_tagUsecase "go-boilerplate/tag/usecase"
 	_userHttDelivery "go-boilerplate/user/delivery/http"
 	_userPostgreRepository "go-boilerplate/user/repository/postgresql"
 	_userUsecase "go-boilerplate/user/usecase"
@@ -34,6 +51,13 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	server := &http.Server{
 		Addr:         ":" + viper.GetString("app_port"),
 		ReadTimeout:  time.Duration(viper.GetInt("READ_TIMEOUT")) * time.Second,
@@ -45,15 +69,25 @@ func main() {
 	timeoutCtx := time.Duration(viper.GetInt("CTX_TIMEOUT")) * time.Second
 
 	e := echo.New()
+	ipExtractor, err := helper.IPExtractor()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	e.IPExtractor = ipExtractor
+	e.Use(MiddlewareCustom.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "If-Match", "If-None-Match"},
+		ExposeHeaders: []string{"ETag", echo.HeaderXRequestID},
 	}))
 
-	CustomMiddleware := MiddlewareCustom.Init()
+	userRepo := _userPostgreRepository.NewPsqlUserRepository(postgreSQL)
+	refreshTokenRepo := _userPostgreRepository.NewPsqlRefreshTokenRepository(postgreSQL)
+
+	CustomMiddleware := MiddlewareCustom.Init(userRepo, refreshTokenRepo)
 	e.HTTPErrorHandler = CustomMiddleware.ErrorHandler
 	MiddlewareCustom.Logger = logrus.New()
 	e.Logger = MiddlewareCustom.GetEchoLogger()
@@ -69,20 +103,56 @@ func main() {
 		return c.String(http.StatusOK, "Server up!")
 	})
 
-	userRepo := _userPostgreRepository.NewPsqlUserRepository(postgreSQL)
-	userUsecase := _userUsecase.NewUserUsecase(userRepo, timeoutCtx)
-	_userHttDelivery.NewUserHandler(e, userUsecase)
+	roleRepo := _rolePostgreRepository.NewPsqlRoleRepository(postgreSQL)
+	roleUsecase := _roleUsecase.NewRoleUsecase(roleRepo, userRepo, timeoutCtx)
+	_roleHttpDelivery.NewRoleHandler(e, roleUsecase, CustomMiddleware)
 
 	articleRepo := _articlePostgreRepository.NewPsqlArticleRepository(postgreSQL)
-	articleUsecase := _articleUsecase.NewArticleUsecase(articleRepo, timeoutCtx)
-	_articleHttpDelivery.NewArticleHandler(e, articleUsecase)
+	passwordResetRepo := _userPostgreRepository.NewPsqlPasswordResetRepository(postgreSQL)
+	userUsecase := _userUsecase.NewUserUsecase(userRepo, refreshTokenRepo, passwordResetRepo, roleRepo, articleRepo, mail.NewMailer(), postgresql.NewTransactor(postgreSQL), timeoutCtx)
+	_userHttDelivery.NewUserHandler(e, userUsecase, CustomMiddleware)
 
-	quit := make(chan os.Signal)
+	articleScheduler := _articleScheduler.NewScheduler(articleRepo, time.Duration(viper.GetInt("ARTICLE_SCHEDULER_INTERVAL"))*time.Second)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	go articleScheduler.Run(schedulerCtx)
+
+	mediaRepo := _mediaPostgreRepository.NewPsqlMediaRepository(postgreSQL)
+	mediaUsecase := _mediaUsecase.NewMediaUsecase(mediaRepo, _mediaStorage.NewStorage(), timeoutCtx)
+	_mediaHttpDelivery.NewMediaHandler(e, mediaUsecase, CustomMiddleware)
+	if _mediaStorage.IsLocal() {
+		e.Static(_mediaStorage.LocalRoute, _mediaStorage.LocalPath())
+	}
+
+	viewCounter := _articleViews.NewCounter(articleRepo, time.Duration(viper.GetInt("ARTICLE_VIEW_WINDOW"))*time.Minute, time.Duration(viper.GetInt("ARTICLE_VIEW_FLUSH_INTERVAL"))*time.Second)
+	viewCounterCtx, stopViewCounter := context.WithCancel(context.Background())
+	viewCounterDone := make(chan struct{})
+	go func() {
+		viewCounter.Run(viewCounterCtx)
+		close(viewCounterDone)
+	}()
+
+	tagRepo := _tagPostgreRepository.NewPsqlTagRepository(postgreSQL)
+	articleUsecase := _articleUsecase.NewArticleUsecase(articleRepo, tagRepo, mediaUsecase, articleScheduler, viewCounter, postgresql.NewTransactor(postgreSQL), timeoutCtx)
+	_articleHttpDelivery.NewArticleHandler(e, articleUsecase, CustomMiddleware)
+
+	tagUsecase := _tagUsecase.NewTagUsecase(tagRepo, articleUsecase, timeoutCtx)
+	_tagHttpDelivery.NewTagHandler(e, tagUsecase)
+
+	commentRepo := _commentPostgreRepository.NewPsqlCommentRepository(postgreSQL)
+	commentUsecase := _commentUsecase.NewCommentUsecase(commentRepo, articleRepo, timeoutCtx)
+	_commentHttpDelivery.NewCommentHandler(e, commentUsecase, CustomMiddleware)
+
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
+	stopScheduler()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
 		e.Logger.Fatal(err)
 	}
+
+	// Requests are done by now, write out the views they counted.
+	stopViewCounter()
+	<-viewCounterDone
 }
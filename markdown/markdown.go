@@ -0,0 +1,87 @@
+// Package markdown renders article bodies written in Markdown to HTML that is safe
+// to embed in a page.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	// ExcerptLength is the most characters an excerpt has, the ellipsis included.
+	ExcerptLength = 280
+	// WordsPerMinute is the reading speed ReadingTime assumes.
+	WordsPerMinute = 200
+)
+
+// Document is a rendered Markdown source.
+type Document struct {
+	HTML    string
+	Excerpt string
+	// ReadingTime is in whole minutes, at least one for any text.
+	ReadingTime int
+}
+
+// Raw HTML in the source is dropped by goldmark, the allow-list is there for
+// whatever still gets through, e.g. javascript: links.
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = newPolicy()
+	stripper = bluemonday.StrictPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.RequireNoReferrerOnLinks(true)
+	return p
+}
+
+// Render turns source into sanitized HTML and derives the excerpt and reading time
+// from its text.
+func Render(source string) (*Document, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return nil, err
+	}
+
+	safe := policy.SanitizeBytes(buf.Bytes())
+	text := strings.Join(strings.Fields(html.UnescapeString(string(stripper.SanitizeBytes(safe)))), " ")
+	return &Document{
+		HTML:        string(safe),
+		Excerpt:     Excerpt(text),
+		ReadingTime: ReadingTime(text),
+	}, nil
+}
+
+// Excerpt shortens text to ExcerptLength characters, cutting at a word boundary.
+func Excerpt(text string) string {
+	if utf8.RuneCountInString(text) <= ExcerptLength {
+		return text
+	}
+
+	runes := []rune(text)[:ExcerptLength-1]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:!?-") + "…"
+}
+
+// ReadingTime estimates how many minutes it takes to read text.
+func ReadingTime(text string) int {
+	words := len(strings.Fields(text))
+	if words == 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
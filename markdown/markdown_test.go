@@ -0,0 +1,107 @@
+package markdown_test
+
+import (
+	"go-boilerplate/markdown"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		contains    []string
+		notContains []string
+		excerpt     string
+	}{
+		{name: "emphasis", source: "Some **bold** text",
+			contains: []string{"<strong>bold</strong>"}, excerpt: "Some bold text"},
+		{name: "raw html is dropped", source: "Hi <script>alert(1)</script> there",
+			notContains: []string{"<script", "alert(1)</script>"}},
+		{name: "javascript link", source: "[click](javascript:alert(1))",
+			notContains: []string{"javascript:"}, excerpt: "click"},
+		{name: "links are not followed", source: "[site](https://example.com)",
+			contains: []string{`href="https://example.com"`, `rel="nofollow noreferrer"`}},
+		{name: "code language", source: "```go\nfmt.Println()\n```",
+			contains: []string{`<code class="language-go">`}},
+		{name: "odd code language", source: "```go\" onclick=\"x\nfmt.Println()\n```",
+			notContains: []string{"onclick"}},
+		{name: "task list", source: "- [x] done",
+			contains: []string{`<input checked="" disabled="" type="checkbox"`}},
+		{name: "table", source: "| a |\n|---|\n| b |",
+			contains: []string{"<table>", "<td>b</td>"}},
+		{name: "entities in the excerpt", source: "Fish &amp; chips",
+			excerpt: "Fish & chips"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := markdown.Render(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(doc.HTML, want) {
+					t.Errorf("HTML lacks %s: %s", want, doc.HTML)
+				}
+			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(doc.HTML, unwanted) {
+					t.Errorf("HTML has %s: %s", unwanted, doc.HTML)
+				}
+			}
+			if tt.excerpt != "" && doc.Excerpt != tt.excerpt {
+				t.Errorf("excerpt = %q, want %q", doc.Excerpt, tt.excerpt)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "short", text: "A short text.", want: "A short text."},
+		{name: "exactly the length", text: strings.Repeat("a", markdown.ExcerptLength), want: strings.Repeat("a", markdown.ExcerptLength)},
+		{name: "cut at a word", text: long, want: strings.TrimSpace(long[:275]) + "…"},
+		{name: "one long word", text: strings.Repeat("ж", 300), want: strings.Repeat("ж", markdown.ExcerptLength-1) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := markdown.Excerpt(tt.text)
+			if got != tt.want {
+				t.Errorf("Excerpt() = %q, want %q", got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > markdown.ExcerptLength {
+				t.Errorf("excerpt has %d characters, want at most %d", n, markdown.ExcerptLength)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		words int
+		want  int
+	}{
+		{"empty", 0, 0},
+		{"one word", 1, 1},
+		{"a minute", markdown.WordsPerMinute, 1},
+		{"just over a minute", markdown.WordsPerMinute + 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdown.ReadingTime(strings.Repeat("word ", tt.words)); got != tt.want {
+				t.Errorf("ReadingTime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/middleware"
+	"net/http"
+)
+
+type mediaHandler struct {
+	mediaUsecase domain.MediaUsecase
+}
+
+func NewMediaHandler(e *echo.Echo, usecase domain.MediaUsecase, customMiddleware *middleware.Middleware) {
+	handler := &mediaHandler{mediaUsecase: usecase}
+	media := e.Group("/media")
+
+	// Leave a megabyte on top of the file for the rest of the multipart body.
+	bodyLimit := echoMiddleware.BodyLimit(fmt.Sprintf("%dK", helper.MediaMaxSize()/1024+1024))
+
+	media.POST("", handler.StoreMediaHandler, bodyLimit, customMiddleware.Auth, customMiddleware.RequireVerified)
+	media.GET("/:id", handler.GetMediaHandler)
+	media.DELETE("/:id", handler.DestroyMediaHandler, customMiddleware.Auth)
+}
+
+func (m mediaHandler) StoreMediaHandler(e echo.Context) error {
+	file, err := e.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, map[string][]string{
+			"file": {"The file field is required"},
+		}).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+	}
+	defer content.Close()
+
+	res, err := m.mediaUsecase.Upload(ctx, &domain.MediaUpload{
+		Filename: file.Filename,
+		Size:     file.Size,
+		Content:  content,
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusCreated, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (m mediaHandler) GetMediaHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := m.mediaUsecase.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (m mediaHandler) DestroyMediaHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	if err := m.mediaUsecase.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	database "go-boilerplate/db/postgresql"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+)
+
+type psqlMediaRepository struct {
+	DB *pg.DB
+}
+
+func NewPsqlMediaRepository(db *pg.DB) domain.MediaRepository {
+	return &psqlMediaRepository{DB: db}
+}
+
+func (m *psqlMediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	if _, err := m.DB.ModelContext(ctx, media).Insert(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	return nil
+}
+
+func (m *psqlMediaRepository) Find(ctx context.Context, id uuid.UUID) (media *domain.Media, err error) {
+	media = new(domain.Media)
+	if err := m.DB.ModelContext(ctx, media).Where("id = ?", id).First(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return media, nil
+}
+
+func (m *psqlMediaRepository) FindMany(ctx context.Context, ids []uuid.UUID) (res []domain.Media, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := m.DB.ModelContext(ctx, &res).Where("id IN (?)", pg.In(ids)).Select(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return res, nil
+}
+
+func (m *psqlMediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := m.DB.ModelContext(ctx, (*domain.Media)(nil)).Where("id = ?", id).Delete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
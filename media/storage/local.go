@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"go-boilerplate/domain"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+type localStorage struct {
+	Root    string
+	BaseURL string
+}
+
+// NewLocalStorage returns a MediaStorage that writes below root, files are expected to
+// be served from baseURL.
+func NewLocalStorage(root, baseURL string) domain.MediaStorage {
+	return &localStorage{Root: root, BaseURL: baseURL}
+}
+
+func (l *localStorage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+	full := l.file(key)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+
+	// Write next to the target and rename, so a file is never served half written.
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), full)
+}
+
+func (l *localStorage) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(l.file(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *localStorage) URL(key string) string {
+	return joinURL(l.BaseURL, key)
+}
+
+func (l *localStorage) file(key string) string {
+	// Clean as an absolute path first so no key can climb out of the root.
+	return filepath.Join(l.Root, filepath.FromSlash(path.Clean("/"+key)))
+}
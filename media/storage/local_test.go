@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageStaysInItsRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "media")
+	s := NewLocalStorage(root, "/media/files")
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "plain", key: "2021/02/photo.png", want: "2021/02/photo.png"},
+		{name: "parent directories", key: "../../outside.png", want: "outside.png"},
+		{name: "parent directories inside", key: "2021/../../outside.png", want: "outside.png"},
+		{name: "absolute", key: "/etc/passwd", want: "etc/passwd"},
+		{name: "backslashes stay in the name", key: `..\outside.png`, want: `..\outside.png`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Put(ctx, tt.key, []byte("content"), "image/png"); err != nil {
+				t.Fatal(err)
+			}
+			want := filepath.Join(root, filepath.FromSlash(tt.want))
+			if got, err := ioutil.ReadFile(want); err != nil || string(got) != "content" {
+				t.Errorf("%s: %q, %v, want the content", want, got, err)
+			}
+
+			if err := s.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(want); !os.IsNotExist(err) {
+				t.Errorf("%s was not deleted, err = %v", want, err)
+			}
+		})
+	}
+
+	// Nothing may have been written next to the root.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "media" {
+		t.Errorf("files outside the root: %v", entries)
+	}
+	if err := s.Delete(ctx, "../media"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("root is gone: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Package storage holds the backends uploaded media is kept in.
+package storage
+
+import (
+	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewStorage picks the MediaStorage configured by MEDIA_DRIVER, "s3" or "local" (the default).
+func NewStorage() domain.MediaStorage {
+	switch viper.GetString("MEDIA_DRIVER") {
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  viper.GetString("MEDIA_S3_ENDPOINT"),
+			Region:    viper.GetString("MEDIA_S3_REGION"),
+			Bucket:    viper.GetString("MEDIA_S3_BUCKET"),
+			AccessKey: viper.GetString("MEDIA_S3_ACCESS_KEY"),
+			SecretKey: viper.GetString("MEDIA_S3_SECRET_KEY"),
+			PathStyle: viper.GetBool("MEDIA_S3_PATH_STYLE"),
+			PublicURL: viper.GetString("MEDIA_URL"),
+		}, &http.Client{Timeout: time.Minute})
+	default:
+		baseURL := viper.GetString("MEDIA_URL")
+		if baseURL == "" {
+			baseURL = helper.AppURL(LocalRoute, nil)
+		}
+		return NewLocalStorage(LocalPath(), baseURL)
+	}
+}
+
+// LocalRoute is where files kept by the local storage are served from.
+const LocalRoute = "/media/files"
+
+// LocalPath is the directory the local storage writes to, MEDIA_LOCAL_PATH.
+func LocalPath() string {
+	if path := viper.GetString("MEDIA_LOCAL_PATH"); path != "" {
+		return path
+	}
+	return "storage/media"
+}
+
+// IsLocal tells whether uploads are kept on the local disk and have to be served by us.
+func IsLocal() bool {
+	return viper.GetString("MEDIA_DRIVER") != "s3"
+}
+
+func joinURL(base, path string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
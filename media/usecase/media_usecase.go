@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/logging"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// extensions are the file extensions stored media gets, by sniffed MIME type. They are
+// also all the types that can be uploaded, whatever MEDIA_ALLOWED_TYPES lists: sniffing
+// cannot tell a zip from a docx or jar, nor plain text from a script.
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type mediaUsecase struct {
+	MediaRepo      domain.MediaRepository
+	Storage        domain.MediaStorage
+	ContextTimeout time.Duration
+}
+
+func (m *mediaUsecase) Upload(ctx context.Context, upload *domain.MediaUpload) (res *domain.Media, err error) {
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := helper.MediaMaxSize()
+	if upload.Size > maxSize {
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	// The declared size is only a hint, stop reading one byte past the limit.
+	content, err := ioutil.ReadAll(io.LimitReader(upload.Content, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	// The type is sniffed from the content, whatever the client claims.
+	mimeType := strings.TrimSpace(strings.SplitN(http.DetectContentType(content), ";", 2)[0])
+	if !allowed(mimeType) {
+		return nil, domain.ErrUnsupportedMediaType
+	}
+
+	now := time.Now()
+	media := &domain.Media{
+		ID:        uuid.New(),
+		OwnerID:   principal.UserID,
+		Filename:  filename(upload.Filename),
+		MimeType:  mimeType,
+		Size:      int64(len(content)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	dir := now.Format("2006/01/")
+	media.Path = dir + media.ID.String() + extensions[mimeType]
+
+	var thumb *thumbnail
+	if media.IsImage() {
+		thumb, err = makeThumbnail(content)
+		if err != nil {
+			return nil, err
+		}
+		media.Width, media.Height = thumb.Width, thumb.Height
+		media.ThumbnailPath = dir + media.ID.String() + "_thumb" + extensions[thumb.ContentType]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.ContextTimeout)
+	defer cancel()
+
+	if err := m.Storage.Put(ctx, media.Path, content, mimeType); err != nil {
+		return nil, err
+	}
+	if thumb != nil {
+		if err := m.Storage.Put(ctx, media.ThumbnailPath, thumb.Content, thumb.ContentType); err != nil {
+			m.removeFiles(ctx, media)
+			return nil, err
+		}
+	}
+
+	if err := m.MediaRepo.Create(ctx, media); err != nil {
+		m.removeFiles(ctx, media)
+		return nil, err
+	}
+
+	m.withURLs(media)
+	return media, nil
+}
+
+func (m *mediaUsecase) Find(ctx context.Context, id uuid.UUID) (res *domain.Media, err error) {
+	ctx, cancel := context.WithTimeout(ctx, m.ContextTimeout)
+	defer cancel()
+
+	media, err := m.MediaRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.withURLs(media)
+	return media, nil
+}
+
+func (m *mediaUsecase) FindMany(ctx context.Context, ids []uuid.UUID) (res map[uuid.UUID]*domain.Media, err error) {
+	ctx, cancel := context.WithTimeout(ctx, m.ContextTimeout)
+	defer cancel()
+
+	found, err := m.MediaRepo.FindMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res = make(map[uuid.UUID]*domain.Media, len(found))
+	for i := range found {
+		m.withURLs(&found[i])
+		res[found[i].ID] = &found[i]
+	}
+	return res, nil
+}
+
+func (m *mediaUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, m.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	media, err := m.MediaRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if media.OwnerID != principal.UserID && !principal.Can(domain.PermissionMediaManage) {
+		return domain.ErrForbidden
+	}
+
+	// Articles using it as cover lose it through the foreign key.
+	if err := m.MediaRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	m.removeFiles(ctx, media)
+	return nil
+}
+
+// removeFiles deletes the stored files of media. Failures only leave orphaned files
+// behind, so they are logged rather than returned.
+func (m *mediaUsecase) removeFiles(ctx context.Context, media *domain.Media) {
+	for _, path := range []string{media.Path, media.ThumbnailPath} {
+		if path == "" {
+			continue
+		}
+		if err := m.Storage.Delete(ctx, path); err != nil {
+			logging.FromContext(ctx).Warnln(err)
+		}
+	}
+}
+
+func (m *mediaUsecase) withURLs(media *domain.Media) {
+	media.URL = m.Storage.URL(media.Path)
+	if media.ThumbnailPath != "" {
+		media.ThumbnailURL = m.Storage.URL(media.ThumbnailPath)
+	}
+}
+
+func allowed(mimeType string) bool {
+	if _, ok := extensions[mimeType]; !ok {
+		return false
+	}
+	for _, t := range helper.MediaAllowedTypes() {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// filename keeps the base name of what the client called the file, for display only.
+func filename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		name = "upload"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+func NewMediaUsecase(mediaRepo domain.MediaRepository, storage domain.MediaStorage, duration time.Duration) domain.MediaUsecase {
+	return &mediaUsecase{
+		MediaRepo:      mediaRepo,
+		Storage:        storage,
+		ContextTimeout: duration,
+	}
+}
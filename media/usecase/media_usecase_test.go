@@ -0,0 +1,153 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/media/usecase"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mediaRepository keeps media in memory, it only implements what Upload uses.
+type mediaRepository struct {
+	domain.MediaRepository
+	mu    sync.Mutex
+	media map[uuid.UUID]*domain.Media
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.media[media.ID] = media
+	return nil
+}
+
+// storage keeps files in memory.
+type storage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (s *storage) Put(ctx context.Context, path string, content []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[path] = content
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, path)
+	return nil
+}
+
+func (s *storage) URL(path string) string {
+	return "/media/files/" + path
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// hugePNG is a small PNG whose header claims a width and height beyond the pixel limit.
+// Its pixel data does not match the header, so it only fails as too large if the limit
+// is checked before decoding.
+func hugePNG(t *testing.T) []byte {
+	t.Helper()
+	content := encodePNG(t, 1, 1)
+	// The IHDR chunk follows the 8 byte signature: length, type, width, height, ... and a CRC.
+	binary.BigEndian.PutUint32(content[16:], 10000)
+	binary.BigEndian.PutUint32(content[20:], 10000)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(content[12:29]))
+	return content
+}
+
+func TestUpload(t *testing.T) {
+	viper.Set("MEDIA_MAX_SIZE", 4096)
+	viper.Set("MEDIA_ALLOWED_TYPES", "image/png,application/pdf,text/plain,application/zip")
+	t.Cleanup(func() {
+		viper.Set("MEDIA_MAX_SIZE", nil)
+		viper.Set("MEDIA_ALLOWED_TYPES", nil)
+	})
+
+	tests := []struct {
+		name      string
+		filename  string
+		size      int64
+		content   []byte
+		wantErr   error
+		wantType  string
+		wantFiles int
+	}{
+		{name: "image", filename: "photo.png", content: encodePNG(t, 8, 4), wantType: "image/png", wantFiles: 2},
+		{name: "document", filename: "paper.pdf", content: []byte("%PDF-1.4\n"), wantType: "application/pdf", wantFiles: 1},
+		{name: "declared too large", filename: "photo.png", size: 4097, content: encodePNG(t, 1, 1),
+			wantErr: domain.ErrMediaTooLarge},
+		{name: "larger than declared", filename: "photo.png", size: 10, content: bytes.Repeat([]byte("%PDF"), 1025),
+			wantErr: domain.ErrMediaTooLarge},
+		{name: "type not allowed", filename: "photo.png", content: []byte("GIF89a"), wantErr: domain.ErrUnsupportedMediaType},
+		{name: "html named as an image", filename: "photo.png", content: []byte("<html><script>alert(1)</script>"),
+			wantErr: domain.ErrUnsupportedMediaType},
+		{name: "plain text even when configured", filename: "notes.txt", content: []byte("just some notes"),
+			wantErr: domain.ErrUnsupportedMediaType},
+		{name: "zip even when configured", filename: "report.docx", content: []byte("PK\x03\x04\x14\x00"),
+			wantErr: domain.ErrUnsupportedMediaType},
+		{name: "too many pixels", filename: "bomb.png", content: hugePNG(t), wantErr: domain.ErrMediaTooLarge},
+		{name: "broken image", filename: "photo.png", content: encodePNG(t, 1, 1)[:40],
+			wantErr: domain.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mediaRepository{media: map[uuid.UUID]*domain.Media{}}
+			files := &storage{files: map[string][]byte{}}
+			u := usecase.NewMediaUsecase(repo, files, time.Second)
+			ctx := helper.WithPrincipal(context.Background(), &domain.Principal{UserID: uuid.New()})
+
+			size := tt.size
+			if size == 0 {
+				size = int64(len(tt.content))
+			}
+			media, err := u.Upload(ctx, &domain.MediaUpload{Filename: tt.filename, Size: size, Content: bytes.NewReader(tt.content)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(files.files) != tt.wantFiles {
+				t.Errorf("stored %d files, want %d", len(files.files), tt.wantFiles)
+			}
+			if tt.wantErr != nil {
+				if len(repo.media) != 0 {
+					t.Error("rejected upload was saved")
+				}
+				return
+			}
+
+			if media.MimeType != tt.wantType || media.Filename != tt.filename {
+				t.Errorf("got %s %q, want %s %q", media.MimeType, media.Filename, tt.wantType, tt.filename)
+			}
+			if !strings.HasPrefix(media.URL, "/media/files/") || media.IsImage() != (media.ThumbnailURL != "") {
+				t.Errorf("urls %q and %q", media.URL, media.ThumbnailURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"bytes"
+	"go-boilerplate/domain"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decodes WebP uploads
+	"image"
+	_ "image/gif" // decodes GIF uploads
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// thumbnailSize is the longest side of a thumbnail in pixels.
+	thumbnailSize = 320
+	// maxPixels guards against small files that decode into huge images.
+	maxPixels = 40_000_000
+)
+
+// thumbnail is a downscaled copy of an uploaded image.
+type thumbnail struct {
+	Content     []byte
+	ContentType string
+	// Width and Height are those of the original image.
+	Width  int
+	Height int
+}
+
+// makeThumbnail scales content down to fit thumbnailSize. Images that may have
+// transparency become PNGs, everything else a JPEG.
+func makeThumbnail(content []byte) (*thumbnail, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, domain.ErrUnsupportedMediaType
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, domain.ErrUnsupportedMediaType
+	}
+
+	width, height := config.Width, config.Height
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			width, height = thumbnailSize, height*thumbnailSize/width
+		} else {
+			width, height = width*thumbnailSize/height, thumbnailSize
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	thumb := &thumbnail{Width: config.Width, Height: config.Height}
+	var buf bytes.Buffer
+	switch format {
+	case "png", "gif", "webp":
+		thumb.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	default:
+		thumb.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	thumb.Content = buf.Bytes()
+	return thumb, nil
+}
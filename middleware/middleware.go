@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"encoding/json"
 	"errors"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/logging"
 	"io"
 	"net/http"
 	"strconv"
@@ -14,10 +18,13 @@ import (
 	"time"
 )
 
-type Middleware struct {}
+type Middleware struct {
+	UserRepo         domain.UserRepository
+	RefreshTokenRepo domain.RefreshTokenRepository
+}
 
-func Init() *Middleware {
-	return &Middleware{}
+func Init(userRepo domain.UserRepository, refreshTokenRepo domain.RefreshTokenRepository) *Middleware {
+	return &Middleware{UserRepo: userRepo, RefreshTokenRepo: refreshTokenRepo}
 }
 
 func makeLogEntry(c echo.Context) *logrus.Entry {
@@ -27,11 +34,11 @@ func makeLogEntry(c echo.Context) *logrus.Entry {
 		})
 	}
 
-	return logrus.WithFields(logrus.Fields{
-		"at": time.Now().Format("2006-01-02 15:04:05"),
+	return logging.FromContext(c.Request().Context()).WithFields(logrus.Fields{
+		"at":     time.Now().Format("2006-01-02 15:04:05"),
 		"method": c.Request().Method,
-		"uri": c.Request().URL.String(),
-		"ip": c.Request().RemoteAddr,
+		"uri":    c.Request().URL.String(),
+		"ip":     c.Request().RemoteAddr,
 	})
 }
 
@@ -42,58 +49,245 @@ func (m *Middleware) MiddlewareLogging(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// maxRequestIDLength bounds the request IDs taken over from clients.
+const maxRequestIDLength = 128
+
+// RequestID tags every request with an ID, the one the client sent in X-Request-ID
+// or a new one. The ID is returned in the response and added to the request context,
+// where logging.FromContext picks it up.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(req.WithContext(logging.WithRequestID(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// validRequestID only accepts IDs of printable ASCII, keeping what clients send from
+// breaking up log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrorHandler answers with the status and code the kind of err calls for. Handlers
+// may return domain errors as they are or wrap them in an echo.HTTPError to pick the status.
+// The answer is application/problem+json when wantsProblem says so.
 func (m *Middleware) ErrorHandler(err error, c echo.Context) {
 	report, ok := err.(*echo.HTTPError)
 	if !ok {
-		report = echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		report = echo.NewHTTPError(errorStatus(err), err.Error()).SetInternal(err)
 	}
 
-	if report.Internal == nil{
-		report.SetInternal(errors.New(""))
-	}
+	code := errorCode(report.Code, report.Internal)
+	makeLogEntry(c).WithFields(logrus.Fields{
+		"code":     code,
+		"internal": report.Internal,
+	}).Error(report.Message)
 
+	lang := negotiateLanguage(c)
+	p := newProblem(c, report, code, lang)
+	if wantsProblem(c) {
+		body, err := json.Marshal(p)
+		if err != nil {
+			makeLogEntry(c).Error(err)
+			return
+		}
+		c.Response().Header().Set("Content-Language", lang)
+		c.Blob(report.Code, MIMEProblemJSON, body)
+		return
+	}
 
-	makeLogEntry(c).Error(report.Message)
+	message, errs := p.Detail, report.Message
+	switch {
+	case p.Detail == "":
+		message, errs = p.Title, nil
+	case p.InvalidParams == nil && p.Extensions == nil:
+		errs = p.Detail
+	}
 	c.JSON(report.Code, map[string]map[string]interface{}{
 		"error": {
-			"code": report.Code,
-			"message": report.Internal.Error(),
-			"errors":  report.Message,
+			"code":       code,
+			"status":     report.Code,
+			"message":    message,
+			"errors":     errs,
+			"request_id": p.RequestID,
 		},
 	})
 }
 
+// errorKinds are the statuses and codes errors of each kind are answered with.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrValidation, http.StatusUnprocessableEntity, "validation_failed"},
+}
 
+// errorStatuses are the errors HTTP has a more precise status for than their kind's.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{domain.ErrVersionConflict, http.StatusPreconditionFailed},
+	{domain.ErrPreconditionRequired, http.StatusPreconditionRequired},
+	{domain.ErrVerificationCooldown, http.StatusTooManyRequests},
+	{domain.ErrMediaTooLarge, http.StatusRequestEntityTooLarge},
+	{domain.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+}
 
+func errorStatus(err error) int {
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return e.status
+		}
+	}
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			return k.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// errorCode names the error for clients, by the domain error behind it when there is
+// one and by its status otherwise.
+func errorCode(status int, err error) string {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) || status == k.status {
+			return k.code
+		}
+	}
+	if status == http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// Auth rejects requests without a valid, unrevoked access token and stores the
+// principal it was issued to for the handlers and usecases further down.
 func (m *Middleware) Auth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		tokenString := c.Request().Header.Get("Authorization")
-
-		if !strings.Contains(tokenString, "Bearer") {
+		if !strings.Contains(c.Request().Header.Get("Authorization"), "Bearer") {
 			return echo.NewHTTPError(http.StatusUnauthorized, errors.New("Token not provided"))
 		}
 
-		tokenString = strings.Replace(tokenString, "Bearer ", "", -1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if jwt.SigningMethodHS256 != token.Method {
-				return nil, errors.New("invalid token")
-			}
+		if err := m.authenticate(c); err != nil {
+			return err
+		}
 
-			return []byte(viper.GetString("JWT_SECRET")), nil
-		})
+		return next(c)
+	}
+}
 
-		if err != nil {
-			return echo.NewHTTPError(http.StatusUnauthorized, err)
+// OptionalAuth is Auth for routes that are public but behave differently for signed
+// in users. Requests without a token pass through anonymously.
+func (m *Middleware) OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get("Authorization") == "" {
+			return next(c)
+		}
+
+		if err := m.authenticate(c); err != nil {
+			return err
 		}
 
-		if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return next(c)
+	}
+}
+
+func (m *Middleware) authenticate(c echo.Context) error {
+	tokenString := strings.Replace(c.Request().Header.Get("Authorization"), "Bearer ", "", -1)
+
+	principal, err := helper.ParsePrincipal(tokenString)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err)
+	}
+
+	revoked, err := m.RefreshTokenRepo.IsAccessTokenRevoked(c.Request().Context(), principal.TokenID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+	}
+	if revoked {
+		return echo.NewHTTPError(http.StatusUnauthorized, errors.New("token has been revoked"))
+	}
+
+	helper.SetPrincipal(c, principal)
+	return nil
+}
+
+// RequireVerified rejects users that have not verified their email address when
+// EMAIL_VERIFICATION_REQUIRED is on. It has to run after Auth.
+func (m *Middleware) RequireVerified(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !viper.GetBool("EMAIL_VERIFICATION_REQUIRED") {
 			return next(c)
-		} else {
-			return echo.NewHTTPError(http.StatusUnauthorized, err)
 		}
+
+		principal, ok := helper.Principal(c)
+		if !ok {
+			return domain.ErrUnauthenticated
+		}
+
+		user, err := m.UserRepo.Find(c.Request().Context(), principal.UserID)
+		if errors.Is(err, domain.ErrNotFound) {
+			// The account is gone but its token has not expired yet.
+			return domain.ErrUnauthenticated
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.EmailVerifiedAt.IsZero() {
+			return domain.ErrEmailNotVerified
+		}
+
+		return next(c)
 	}
 }
 
+// RequirePermission only lets requests through whose access token grants permission.
+// It has to run after Auth.
+func (m *Middleware) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := helper.Principal(c)
+			if !ok {
+				return domain.ErrUnauthenticated
+			}
+
+			if !principal.Can(permission) {
+				return domain.ErrForbidden
+			}
+
+			return next(c)
+		}
+	}
+}
 
 // Logrus : implement Logger
 type Logrus struct {
@@ -248,6 +442,7 @@ func logrusMiddlewareHandler(c echo.Context, next echo.HandlerFunc) error {
 	bytesIn := req.Header.Get(echo.HeaderContentLength)
 
 	Logger.WithFields(map[string]interface{}{
+		"request_id":    logging.RequestID(req.Context()),
 		"time_rfc3339":  time.Now().Format(time.RFC3339),
 		"remote_ip":     c.RealIP(),
 		"host":          req.Host,
@@ -275,4 +470,4 @@ func logger(next echo.HandlerFunc) echo.HandlerFunc {
 // Hook is a function to process middleware.
 func Hook() echo.MiddlewareFunc {
 	return logger
-}
\ No newline at end of file
+}
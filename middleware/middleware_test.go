@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	"go-boilerplate/logging"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", domain.ErrNotFound, http.StatusNotFound, "not_found"},
+		{"wrapped not found", fmt.Errorf("find article: %w", domain.ErrNotFound), http.StatusNotFound, "not_found"},
+		{"conflict", domain.ErrConflict, http.StatusConflict, "conflict"},
+		{"taken email", domain.ErrEmailTaken, http.StatusConflict, "email_taken"},
+		{"unauthenticated", domain.ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+		{"invalid credentials", domain.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+		{"forbidden", domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{"unverified email", domain.ErrEmailNotVerified, http.StatusForbidden, "email_not_verified"},
+		{"validation", domain.ErrInvalidCursor, http.StatusUnprocessableEntity, "invalid_cursor"},
+		{"version conflict", &domain.VersionConflictError{Current: 3}, http.StatusPreconditionFailed, "version_conflict"},
+		{"cooldown", domain.ErrVerificationCooldown, http.StatusTooManyRequests, "verification_cooldown"},
+		{"unknown", errors.New("connection refused"), http.StatusInternalServerError, "internal_error"},
+		{"http error", echo.NewHTTPError(http.StatusUnprocessableEntity, map[string]string{"email": "required"}),
+			http.StatusUnprocessableEntity, "validation_failed"},
+		{"http error with status only", echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"http error around a domain error", echo.NewHTTPError(http.StatusPreconditionFailed).SetInternal(domain.ErrVersionConflict),
+			http.StatusPreconditionFailed, "version_conflict"},
+	}
+
+	m := &Middleware{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			m.ErrorHandler(tt.err, c)
+
+			var body struct {
+				Error struct {
+					Code   string `json:"code"`
+					Status int    `json:"status"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.wantStatus || body.Error.Status != tt.wantStatus || body.Error.Code != tt.wantCode {
+				t.Errorf("got %d %q, want %d %q: %s", rec.Code, body.Error.Code, tt.wantStatus, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerProblem(t *testing.T) {
+	viper.Set("APP_URL", "http://localhost:1233")
+	t.Cleanup(func() { viper.Set("APP_URL", nil) })
+
+	tests := []struct {
+		name        string
+		err         error
+		header      http.Header
+		production  bool
+		wantStatus  int
+		wantProblem map[string]interface{}
+	}{
+		{
+			name:       "domain error in another language",
+			err:        domain.ErrEmailTaken,
+			header:     http.Header{"Accept": {MIMEProblemJSON}, "Accept-Language": {"id-ID,id;q=0.9,en;q=0.8"}},
+			wantStatus: http.StatusConflict,
+			wantProblem: map[string]interface{}{
+				"type": "http://localhost:1233/problems/email_taken", "title": "Email sudah digunakan", "status": 409.0,
+				"detail": "email address is already taken", "instance": "/user/register?source=test", "code": "email_taken",
+				"request_id": "req-1",
+			},
+		},
+		{
+			name: "field errors",
+			err: echo.NewHTTPError(http.StatusUnprocessableEntity, url.Values{
+				"password": {"The password field is required"}, "email": {"The email field is required"},
+			}).SetInternal(errors.New("invalid parameter")),
+			header:     http.Header{"Accept": {MIMEProblemJSON}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantProblem: map[string]interface{}{
+				"type": "about:blank", "title": "Invalid input", "status": 422.0, "detail": "invalid parameter",
+				"instance": "/user/register?source=test", "code": "validation_failed", "request_id": "req-1",
+				"invalid_params": []interface{}{
+					map[string]interface{}{"name": "email", "reason": "The email field is required"},
+					map[string]interface{}{"name": "password", "reason": "The password field is required"},
+				},
+			},
+		},
+		{
+			name: "extension members",
+			err: echo.NewHTTPError(http.StatusPreconditionFailed, map[string]interface{}{"currentVersion": 3}).
+				SetInternal(&domain.VersionConflictError{Current: 3}),
+			header:     http.Header{"Accept": {MIMEProblemJSON}},
+			wantStatus: http.StatusPreconditionFailed,
+			wantProblem: map[string]interface{}{
+				"type": "http://localhost:1233/problems/version_conflict", "title": "Version conflict", "status": 412.0,
+				"detail": "article has been modified since it was read", "instance": "/user/register?source=test",
+				"code": "version_conflict", "request_id": "req-1", "currentVersion": 3.0,
+			},
+		},
+		{
+			name:       "server error in production",
+			err:        errors.New("pq: password authentication failed"),
+			header:     http.Header{"Accept": {MIMEProblemJSON}},
+			production: true,
+			wantStatus: http.StatusInternalServerError,
+			wantProblem: map[string]interface{}{
+				"type": "about:blank", "title": "Internal server error", "status": 500.0,
+				"instance": "/user/register?source=test", "code": "internal_error", "request_id": "req-1",
+			},
+		},
+	}
+
+	m := &Middleware{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.production {
+				viper.Set("APP_ENV", "production")
+				defer viper.Set("APP_ENV", nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/user/register?source=test", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+
+			m.ErrorHandler(tt.err, c)
+
+			if rec.Code != tt.wantStatus || rec.Header().Get(echo.HeaderContentType) != MIMEProblemJSON {
+				t.Fatalf("got %d %s, want %d %s", rec.Code, rec.Header().Get(echo.HeaderContentType), tt.wantStatus, MIMEProblemJSON)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantProblem) {
+				t.Errorf("got %v\nwant %v", got, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerHidesServerErrorsInProduction(t *testing.T) {
+	viper.Set("APP_ENV", "production")
+	t.Cleanup(func() { viper.Set("APP_ENV", nil) })
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	(&Middleware{}).ErrorHandler(errors.New("pq: password authentication failed"), c)
+
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "password authentication") {
+		t.Errorf("got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"from the client", "c0ffee-1", true},
+		{"missing", "", false},
+		{"with line breaks", "forged\nlevel=error", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, tt.header)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var inContext string
+			err := RequestID()(func(c echo.Context) error {
+				inContext = logging.RequestID(c.Request().Context())
+				return nil
+			})(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			if id == "" || id != inContext {
+				t.Fatalf("response has %q, context has %q", id, inContext)
+			}
+			if (id == tt.header) != tt.keep {
+				t.Errorf("got %q for %q", id, tt.header)
+			}
+		})
+	}
+}
+
+// userRepository answers Find with a fixed user or error, it only implements what RequireVerified uses.
+type userRepository struct {
+	domain.UserRepository
+	user *domain.User
+	err  error
+}
+
+func (r userRepository) Find(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.user, r.err
+}
+
+func TestRequireVerified(t *testing.T) {
+	viper.Set("EMAIL_VERIFICATION_REQUIRED", true)
+	t.Cleanup(func() { viper.Set("EMAIL_VERIFICATION_REQUIRED", nil) })
+
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		repo    userRepository
+		wantErr error
+	}{
+		{"verified", userRepository{user: &domain.User{EmailVerifiedAt: pg.NullTime{Time: time.Now()}}}, nil},
+		{"unverified", userRepository{user: &domain.User{}}, domain.ErrEmailNotVerified},
+		{"deleted account", userRepository{err: domain.ErrNotFound}, domain.ErrUnauthenticated},
+		{"database failure", userRepository{err: failure}, failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+			helper.SetPrincipal(c, &domain.Principal{UserID: uuid.New()})
+
+			err := (&Middleware{UserRepo: tt.repo}).RequireVerified(func(c echo.Context) error { return nil })(c)
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
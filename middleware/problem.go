@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+	"go-boilerplate/logging"
+	"golang.org/x/text/language"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MIMEProblemJSON is the content type of RFC 7807 error responses.
+const MIMEProblemJSON = "application/problem+json"
+
+// problem is an error response as RFC 7807 describes it, with the error code and the
+// request it happened in added.
+type problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance"`
+	Code          string         `json:"code"`
+	RequestID     string         `json:"request_id,omitempty"`
+	InvalidParams []invalidParam `json:"invalid_params,omitempty"`
+
+	// Extensions are further members, such as the version a conflicting article is at.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// invalidParam is a field the request was rejected for.
+type invalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (p problem) MarshalJSON() ([]byte, error) {
+	type members problem
+	body, err := json.Marshal(members(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+
+	merged := make(map[string]interface{}, len(p.Extensions))
+	for name, value := range p.Extensions {
+		merged[name] = value
+	}
+	// The standard members win over extensions of the same name.
+	if err := json.Unmarshal(body, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+// newProblem describes report for the client. Details of server errors stay in the
+// log in production.
+func newProblem(c echo.Context, report *echo.HTTPError, code, lang string) problem {
+	p := problem{
+		Type:      problemType(report.Internal, code),
+		Title:     title(lang, code, report.Code),
+		Status:    report.Code,
+		Detail:    detail(report),
+		Instance:  c.Request().URL.RequestURI(),
+		Code:      code,
+		RequestID: requestID(c),
+	}
+
+	if report.Code >= http.StatusInternalServerError && isProduction() {
+		p.Detail = ""
+		return p
+	}
+
+	switch message := report.Message.(type) {
+	case url.Values:
+		p.InvalidParams = invalidParams(message)
+	case map[string]interface{}:
+		p.Extensions = message
+	}
+	return p
+}
+
+// wantsProblem tells whether errors are answered as application/problem+json, either
+// because ERROR_FORMAT is problem or because the client asked for it.
+func wantsProblem(c echo.Context) bool {
+	return viper.GetString("ERROR_FORMAT") == "problem" ||
+		strings.Contains(c.Request().Header.Get(echo.HeaderAccept), MIMEProblemJSON)
+}
+
+func isProduction() bool {
+	return viper.GetString("APP_ENV") == "production"
+}
+
+// problemType is the URI identifying the kind of problem. Errors only known by their
+// status are about:blank, as RFC 7807 suggests.
+func problemType(err error, code string) string {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			return strings.TrimRight(viper.GetString("APP_URL"), "/") + "/problems/" + code
+		}
+	}
+	return "about:blank"
+}
+
+// detail is the human readable explanation of report, the message the handler gave
+// unless that is not text.
+func detail(report *echo.HTTPError) string {
+	switch message := report.Message.(type) {
+	case string:
+		return message
+	case error:
+		return message.Error()
+	}
+	if report.Internal != nil {
+		return report.Internal.Error()
+	}
+	return ""
+}
+
+func invalidParams(fields url.Values) []invalidParam {
+	var params []invalidParam
+	for name, reasons := range fields {
+		for _, reason := range reasons {
+			params = append(params, invalidParam{Name: name, Reason: reason})
+		}
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+func requestID(c echo.Context) string {
+	if id := logging.RequestID(c.Request().Context()); id != "" {
+		return id
+	}
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
+
+// languages are the languages there are titles in, the first one is the default.
+var languages = []language.Tag{language.English, language.Indonesian}
+
+var languageMatcher = language.NewMatcher(languages)
+
+// negotiateLanguage picks the language of the titles from the Accept-Language header.
+func negotiateLanguage(c echo.Context) string {
+	tags, _, _ := language.ParseAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+	_, index, _ := languageMatcher.Match(tags...)
+	base, _ := languages[index].Base()
+	return base.String()
+}
+
+// title is the short summary of the problem code names in lang, falling back to
+// English and then to the status text.
+func title(lang, code string, status int) string {
+	if t, ok := titles[lang][code]; ok {
+		return t
+	}
+	if t, ok := titles["en"][code]; ok {
+		return t
+	}
+	return http.StatusText(status)
+}
+
+// titles holds the summary of every error code by language.
+var titles = map[string]map[string]string{
+	"en": {
+		"not_found":                  "Resource not found",
+		"conflict":                   "Resource conflict",
+		"unauthorized":               "Unauthorized",
+		"forbidden":                  "Forbidden",
+		"validation_failed":          "Invalid input",
+		"internal_error":             "Internal server error",
+		"unauthenticated":            "Authentication required",
+		"invalid_credentials":        "Invalid credentials",
+		"invalid_cursor":             "Invalid cursor",
+		"invalid_refresh_token":      "Invalid refresh token",
+		"refresh_token_reused":       "Refresh token reused",
+		"invalid_reset_token":        "Invalid password reset token",
+		"invalid_verification_token": "Invalid verification link",
+		"email_already_verified":     "Email already verified",
+		"verification_cooldown":      "Verification mail sent recently",
+		"email_not_verified":         "Email not verified",
+		"email_taken":                "Email already taken",
+		"invalid_password":           "Incorrect password",
+		"slug_taken":                 "Slug already taken",
+		"unknown_language":           "Unknown search language",
+		"diff_too_large":             "Revisions too different to compare",
+		"version_conflict":           "Version conflict",
+		"precondition_required":      "Precondition required",
+		"invalid_tag":                "Invalid tags",
+		"invalid_parent":             "Invalid parent comment",
+		"media_too_large":            "File too large",
+		"unsupported_media_type":     "Unsupported file type",
+		"invalid_cover":              "Invalid cover",
+		"unknown_permission":         "Unknown permission",
+		"role_exists":                "Role already exists",
+	},
+	"id": {
+		"not_found":                  "Data tidak ditemukan",
+		"conflict":                   "Data bentrok dengan data yang sudah ada",
+		"unauthorized":               "Akses tidak sah",
+		"forbidden":                  "Akses ditolak",
+		"validation_failed":          "Masukan tidak valid",
+		"internal_error":             "Terjadi kesalahan pada server",
+		"unauthenticated":            "Autentikasi diperlukan",
+		"invalid_credentials":        "Email atau kata sandi tidak sesuai",
+		"invalid_cursor":             "Kursor tidak valid",
+		"invalid_refresh_token":      "Refresh token tidak valid",
+		"refresh_token_reused":       "Refresh token sudah pernah digunakan",
+		"invalid_reset_token":        "Token reset kata sandi tidak valid",
+		"invalid_verification_token": "Tautan verifikasi tidak valid",
+		"email_already_verified":     "Email sudah diverifikasi",
+		"verification_cooldown":      "Email verifikasi baru saja dikirim",
+		"email_not_verified":         "Email belum diverifikasi",
+		"email_taken":                "Email sudah digunakan",
+		"invalid_password":           "Kata sandi salah",
+		"slug_taken":                 "Slug sudah digunakan",
+		"unknown_language":           "Bahasa pencarian tidak dikenal",
+		"diff_too_large":             "Revisi terlalu berbeda untuk dibandingkan",
+		"version_conflict":           "Konflik versi",
+		"precondition_required":      "Prasyarat diperlukan",
+		"invalid_tag":                "Tag tidak valid",
+		"invalid_parent":             "Komentar induk tidak valid",
+		"media_too_large":            "Berkas terlalu besar",
+		"unsupported_media_type":     "Jenis berkas tidak didukung",
+		"invalid_cover":              "Sampul tidak valid",
+		"unknown_permission":         "Izin tidak dikenal",
+		"role_exists":                "Peran sudah ada",
+	},
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-boilerplate/db/migration"
+	"go-boilerplate/db/postgresql"
+	"io"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: go-boilerplate migrate <command>
+
+commands:
+  up              apply all pending migrations as a new batch
+  down            roll back the last batch
+  status          list migrations and whether they have been applied
+  to <version>    migrate up or down to version, 0 rolls back everything
+`
+
+func runMigrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, migrateUsage)
+		return errors.New("missing migrate command")
+	}
+
+	db := postgresql.Connect()
+	defer db.Close()
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "Nothing to migrate")
+		}
+		printMigrations(out, "Migrated", applied)
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Fprintln(out, "Nothing to roll back")
+		}
+		printMigrations(out, "Rolled back", rolledBack)
+	case "to":
+		if len(args) < 2 {
+			return errors.New("missing target version, e.g. migrate to 2021_02_14_111944")
+		}
+		applied, rolledBack, err := migrator.To(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		if len(applied)+len(rolledBack) == 0 {
+			fmt.Fprintln(out, "Already at", args[1])
+		}
+		printMigrations(out, "Rolled back", rolledBack)
+		printMigrations(out, "Migrated", applied)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RAN\tBATCH\tMIGRATION\tCHECKSUM")
+		for _, s := range status {
+			ran, batch, checksum := "no", "", ""
+			if s.Applied {
+				ran, batch, checksum = "yes", fmt.Sprint(s.Batch), "ok"
+			}
+			if s.Drift {
+				checksum = "drift"
+			}
+			if s.Applied && s.Up == "" {
+				checksum = "missing file"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ran, batch, s.Name, checksum)
+		}
+		return w.Flush()
+	default:
+		fmt.Fprint(out, migrateUsage)
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	return nil
+}
+
+func printMigrations(out io.Writer, verb string, migrations []migration.Migration) {
+	for _, m := range migrations {
+		fmt.Fprintf(out, "%s: %s\n", verb, m.Name)
+	}
+}
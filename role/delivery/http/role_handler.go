@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/thedevsaddam/govalidator"
+	"go-boilerplate/domain"
+	"go-boilerplate/middleware"
+	"net/http"
+	"strings"
+)
+
+type roleHandler struct {
+	roleUsecase domain.RoleUsecase
+}
+
+func NewRoleHandler(e *echo.Echo, usecase domain.RoleUsecase, customMiddleware *middleware.Middleware) {
+	handler := &roleHandler{roleUsecase: usecase}
+	role := e.Group("/role", customMiddleware.Auth, customMiddleware.RequirePermission(domain.PermissionRolesManage))
+
+	role.GET("", handler.FetchRoleHandler)
+	role.POST("", handler.StoreRoleHandler)
+	role.GET("/permissions", handler.FetchPermissionHandler)
+	role.DELETE("/:id", handler.DestroyRoleHandler)
+	role.PUT("/:id/permissions", handler.UpdatePermissionHandler)
+	role.POST("/:id/users/:user_id", handler.AssignUserHandler)
+	role.DELETE("/:id/users/:user_id", handler.UnassignUserHandler)
+}
+
+func (r roleHandler) FetchRoleHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := r.roleUsecase.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (r roleHandler) FetchPermissionHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := r.roleUsecase.FetchPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (r roleHandler) StoreRoleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"name": []string{"required", "alpha_dash", "between:2,50"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	role := domain.Role{
+		Name:        e.FormValue("name"),
+		Permissions: permissionsParam(e),
+	}
+
+	if err := r.roleUsecase.Store(ctx, &role); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusCreated, map[string]interface{}{
+		"status": "success",
+		"data":   role,
+	})
+}
+
+func (r roleHandler) DestroyRoleHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	if err := r.roleUsecase.Destroy(ctx, id); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (r roleHandler) UpdatePermissionHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	res, err := r.roleUsecase.UpdatePermissions(ctx, id, permissionsParam(e))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (r roleHandler) AssignUserHandler(e echo.Context) error {
+	return r.manageAssignment(e, r.roleUsecase.AssignUser)
+}
+
+func (r roleHandler) UnassignUserHandler(e echo.Context) error {
+	return r.manageAssignment(e, r.roleUsecase.UnassignUser)
+}
+
+// manageAssignment runs action for the role in :id and the user in :user_id.
+func (r roleHandler) manageAssignment(e echo.Context, action func(ctx context.Context, id, userID uuid.UUID) error) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	id, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	userID, err := uuid.Parse(e.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	if err := action(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+// permissionsParam accepts the permissions field repeated or as a comma separated list.
+func permissionsParam(e echo.Context) []string {
+	permissions := []string{}
+	params, _ := e.FormParams()
+	for _, value := range params["permissions"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				permissions = append(permissions, name)
+			}
+		}
+	}
+	return permissions
+}
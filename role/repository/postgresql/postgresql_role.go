@@ -0,0 +1,161 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	database "go-boilerplate/db/postgresql"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+)
+
+type psqlRoleRepository struct {
+	DB *pg.DB
+}
+
+// roleQuery selects roles together with the names of the permissions they grant.
+const roleQuery = `
+	SELECT role.id, role.name, role.created_at, role.updated_at,
+		coalesce(array_agg(permission.name ORDER BY permission.name) FILTER (WHERE permission.name IS NOT NULL), '{}') AS permissions
+	FROM roles AS role
+	LEFT JOIN role_permissions ON role_permissions.role_id = role.id
+	LEFT JOIN permissions AS permission ON permission.id = role_permissions.permission_id
+`
+
+func (r *psqlRoleRepository) Fetch(ctx context.Context) (res []domain.Role, err error) {
+	_, err = r.DB.QueryContext(ctx, &res, roleQuery+" GROUP BY role.id ORDER BY role.name")
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return res, nil
+}
+
+func (r *psqlRoleRepository) Find(ctx context.Context, id uuid.UUID) (role *domain.Role, err error) {
+	role = new(domain.Role)
+	_, err = r.DB.QueryOneContext(ctx, role, roleQuery+" WHERE role.id = ? GROUP BY role.id", id)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return role, nil
+}
+
+// Create stores the role and grants its permissions in one transaction.
+func (r *psqlRoleRepository) Create(ctx context.Context, role *domain.Role) error {
+	err := r.DB.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ModelContext(ctx, role).ExcludeColumn("permissions").Insert(); err != nil {
+			return err
+		}
+		return grant(ctx, tx, role.ID, role.Permissions)
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *psqlRoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.DB.ModelContext(ctx, (*domain.Role)(nil)).Where("id = ?", id).Delete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SetPermissions replaces the permissions granted by the role with the named ones.
+func (r *psqlRoleRepository) SetPermissions(ctx context.Context, id uuid.UUID, permissions []string) error {
+	err := r.DB.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = ?", id); err != nil {
+			return err
+		}
+		return grant(ctx, tx, id, permissions)
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// grant lets the role use the named permissions.
+func grant(ctx context.Context, tx *pg.Tx, id uuid.UUID, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT ?, id FROM permissions WHERE name IN (?)`, id, pg.In(permissions))
+	return err
+}
+
+func (r *psqlRoleRepository) FetchPermissions(ctx context.Context) (res []domain.Permission, err error) {
+	err = r.DB.ModelContext(ctx, &res).Order("name ASC").Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return res, nil
+}
+
+func (r *psqlRoleRepository) AssignUser(ctx context.Context, id, userID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING", userID, id)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *psqlRoleRepository) UnassignUser(ctx context.Context, id, userID uuid.UUID) error {
+	res, err := r.DB.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userID, id)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *psqlRoleRepository) FindByUser(ctx context.Context, userID uuid.UUID) (roles, permissions []string, err error) {
+	var res struct {
+		Roles       []string `pg:"roles,array"`
+		Permissions []string `pg:"permissions,array"`
+	}
+
+	_, err = r.DB.QueryOneContext(ctx, &res, `
+		SELECT
+			coalesce(array_agg(DISTINCT role.name), '{}') AS roles,
+			coalesce(array_agg(DISTINCT permission.name) FILTER (WHERE permission.name IS NOT NULL), '{}') AS permissions
+		FROM user_roles
+		JOIN roles AS role ON role.id = user_roles.role_id
+		LEFT JOIN role_permissions ON role_permissions.role_id = role.id
+		LEFT JOIN permissions AS permission ON permission.id = role_permissions.permission_id
+		WHERE user_roles.user_id = ?`, userID)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, nil, translateError(err)
+	}
+	return res.Roles, res.Permissions, nil
+}
+
+func NewPsqlRoleRepository(db *pg.DB) domain.RoleRepository {
+	return &psqlRoleRepository{DB: db}
+}
+
+// uniques are the domain errors a taken unique column of the role tables is reported as.
+var uniques = map[string]error{
+	"roles_name_unique": domain.ErrRoleExists,
+}
+
+// translateError turns query errors into the domain errors the role usecase acts on.
+func translateError(err error) error {
+	return database.TranslateError(err, uniques)
+}
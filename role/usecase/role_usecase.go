@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"time"
+)
+
+// adminRole is seeded by the migrations and can not be deleted.
+const adminRole = "admin"
+
+type roleUsecase struct {
+	RoleRepo       domain.RoleRepository
+	UserRepo       domain.UserRepository
+	ContextTimeout time.Duration
+}
+
+func (r *roleUsecase) Fetch(ctx context.Context) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	roles, err := r.RoleRepo.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []domain.Role{}
+	}
+	return roles, nil
+}
+
+func (r *roleUsecase) FetchPermissions(ctx context.Context) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	permissions, err := r.RoleRepo.FetchPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []domain.Permission{}
+	}
+	return permissions, nil
+}
+
+func (r *roleUsecase) Store(ctx context.Context, role *domain.Role) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	if err := r.validatePermissions(ctx, role.Permissions); err != nil {
+		return err
+	}
+
+	role.ID = uuid.New()
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = time.Now()
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	// A taken name fails with domain.ErrRoleExists.
+	return r.RoleRepo.Create(ctx, role)
+}
+
+func (r *roleUsecase) Destroy(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	role, err := r.RoleRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.Name == adminRole {
+		return domain.ErrForbidden
+	}
+	return r.RoleRepo.Delete(ctx, id)
+}
+
+func (r *roleUsecase) UpdatePermissions(ctx context.Context, id uuid.UUID, permissions []string) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	if _, err := r.RoleRepo.Find(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := r.validatePermissions(ctx, permissions); err != nil {
+		return nil, err
+	}
+
+	if err := r.RoleRepo.SetPermissions(ctx, id, permissions); err != nil {
+		return nil, err
+	}
+
+	return r.RoleRepo.Find(ctx, id)
+}
+
+func (r *roleUsecase) AssignUser(ctx context.Context, id, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	if _, err := r.RoleRepo.Find(ctx, id); err != nil {
+		return err
+	}
+	if _, err := r.UserRepo.Find(ctx, userID); err != nil {
+		return err
+	}
+	return r.RoleRepo.AssignUser(ctx, id, userID)
+}
+
+func (r *roleUsecase) UnassignUser(ctx context.Context, id, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, r.ContextTimeout)
+	defer cancel()
+
+	return r.RoleRepo.UnassignUser(ctx, id, userID)
+}
+
+// validatePermissions makes sure every name refers to an existing permission.
+func (r *roleUsecase) validatePermissions(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	permissions, err := r.RoleRepo.FetchPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	for _, p := range permissions {
+		known[p.Name] = true
+	}
+
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownPermission, name)
+		}
+	}
+	return nil
+}
+
+func NewRoleUsecase(roleRepo domain.RoleRepository, userRepo domain.UserRepository, duration time.Duration) domain.RoleUsecase {
+	return &roleUsecase{
+		RoleRepo:       roleRepo,
+		UserRepo:       userRepo,
+		ContextTimeout: duration,
+	}
+}
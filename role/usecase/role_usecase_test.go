@@ -0,0 +1,165 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"go-boilerplate/role/usecase"
+	"sync"
+	"testing"
+	"time"
+)
+
+// roleRepository keeps roles in memory, it only implements what the role usecase uses.
+type roleRepository struct {
+	domain.RoleRepository
+	mu    sync.Mutex
+	roles map[uuid.UUID]domain.Role
+}
+
+func (r *roleRepository) Find(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) Create(ctx context.Context, role *domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.roles {
+		if existing.Name == role.Name {
+			return domain.ErrRoleExists
+		}
+	}
+	r.roles[role.ID] = *role
+	return nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.roles, id)
+	return nil
+}
+
+func (r *roleRepository) SetPermissions(ctx context.Context, id uuid.UUID, permissions []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.roles[id]
+	role.Permissions = permissions
+	r.roles[id] = role
+	return nil
+}
+
+func (r *roleRepository) FetchPermissions(ctx context.Context) ([]domain.Permission, error) {
+	return []domain.Permission{{Name: domain.PermissionArticlesUpdate}, {Name: domain.PermissionRolesManage}}, nil
+}
+
+func newUsecase() (*roleRepository, domain.RoleUsecase) {
+	repo := &roleRepository{roles: map[uuid.UUID]domain.Role{}}
+	return repo, usecase.NewRoleUsecase(repo, nil, time.Second)
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        domain.Role
+		wantErr     error
+		wantGranted []string
+	}{
+		{name: "with permissions", role: domain.Role{Name: "editor", Permissions: []string{domain.PermissionArticlesUpdate}},
+			wantGranted: []string{domain.PermissionArticlesUpdate}},
+		{name: "without permissions", role: domain.Role{Name: "reader"}, wantGranted: []string{}},
+		{name: "unknown permission", role: domain.Role{Name: "editor", Permissions: []string{"articles:burn"}},
+			wantErr: domain.ErrUnknownPermission},
+		{name: "taken name", role: domain.Role{Name: "admin"}, wantErr: domain.ErrRoleExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, u := newUsecase()
+			admin := domain.Role{ID: uuid.New(), Name: "admin"}
+			repo.roles[admin.ID] = admin
+
+			role := tt.role
+			err := u.Store(context.Background(), &role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.roles) != 1 {
+					t.Errorf("%d roles stored, want only admin", len(repo.roles))
+				}
+				return
+			}
+
+			stored, err := repo.Find(context.Background(), role.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Name != tt.role.Name || len(stored.Permissions) != len(tt.wantGranted) || stored.Permissions == nil {
+				t.Errorf("stored %q granting %v, want %q granting %v", stored.Name, stored.Permissions, tt.role.Name, tt.wantGranted)
+			}
+		})
+	}
+}
+
+func TestUpdatePermissionsRejectsUnknownOnes(t *testing.T) {
+	repo, u := newUsecase()
+	role := domain.Role{ID: uuid.New(), Name: "editor", Permissions: []string{domain.PermissionArticlesUpdate}}
+	repo.roles[role.ID] = role
+
+	_, err := u.UpdatePermissions(context.Background(), role.ID, []string{domain.PermissionRolesManage, "articles:burn"})
+	if !errors.Is(err, domain.ErrUnknownPermission) {
+		t.Fatalf("err = %v, want %v", err, domain.ErrUnknownPermission)
+	}
+	if stored, _ := repo.Find(context.Background(), role.ID); len(stored.Permissions) != 1 {
+		t.Errorf("permissions changed to %v", stored.Permissions)
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		wantErr error
+	}{
+		{name: "custom role", role: "editor"},
+		{name: "admin role", role: "admin", wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, u := newUsecase()
+			role := domain.Role{ID: uuid.New(), Name: tt.role}
+			repo.roles[role.ID] = role
+
+			err := u.Destroy(context.Background(), role.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := repo.Find(context.Background(), role.ID); errors.Is(err, domain.ErrNotFound) != (tt.wantErr == nil) {
+				t.Errorf("deleted = %v, want %v", err != nil, tt.wantErr == nil)
+			}
+		})
+	}
+
+	t.Run("missing role", func(t *testing.T) {
+		_, u := newUsecase()
+		if err := u.Destroy(context.Background(), uuid.New()); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("err = %v, want %v", err, domain.ErrNotFound)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package slug_test
+
+import (
+	"go-boilerplate/slug"
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"accents", "Crème Brûlée: 10 Tips!", "creme-brulee-10-tips"},
+		{"cyrillic", "Привет, мир", "privet-mir"},
+		{"greek", "Ελληνικά", "ellinika"},
+		{"letters without a decomposition", "Straße Øresund Łódź", "strasse-oresund-lodz"},
+		{"symbols spelled out", "Rock & Roll @ Home", "rock-and-roll-at-home"},
+		{"surrounding punctuation", "  --Hello--  ", "hello"},
+		{"nothing to transliterate", "日本語", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slug.Make(tt.title); got != tt.want {
+				t.Errorf("Make(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeCutsLongTitlesAtAWord(t *testing.T) {
+	got := slug.Make(strings.Repeat("word ", 60))
+	if len(got) > slug.MaxLength || strings.HasSuffix(got, "-") || !strings.HasSuffix(got, "-word") {
+		t.Errorf("got %q (%d bytes)", got, len(got))
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/thedevsaddam/govalidator"
+	"go-boilerplate/domain"
+	"net/http"
+	"strconv"
+)
+
+type tagHandler struct {
+	tagUsecase domain.TagUsecase
+}
+
+func NewTagHandler(e *echo.Echo, usecase domain.TagUsecase) {
+	handler := &tagHandler{tagUsecase: usecase}
+	tag := e.Group("/tag")
+
+	tag.GET("", handler.FetchTagHandler)
+	tag.GET("/:slug/articles", handler.FetchArticleHandler)
+}
+
+func (t tagHandler) FetchTagHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := t.tagUsecase.FetchTags(ctx)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (t tagHandler) FetchArticleHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"limit":  []string{"numeric_between:1,100"},
+		"offset": []string{"numeric"},
+		"sort":   []string{"in:created_at,updated_at,title"},
+		"order":  []string{"in:asc,desc"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	filter := domain.ArticleFilter{
+		Cursor: e.QueryParam("cursor"),
+		Sort:   e.QueryParam("sort"),
+		Order:  e.QueryParam("order"),
+	}
+	filter.Limit, _ = strconv.Atoi(e.QueryParam("limit"))
+	filter.Offset, _ = strconv.Atoi(e.QueryParam("offset"))
+
+	res, err := t.tagUsecase.FetchArticles(ctx, e.Param("slug"), &filter)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
@@ -0,0 +1,124 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	database "go-boilerplate/db/postgresql"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+)
+
+type psqlTagRepository struct {
+	DB *pg.DB
+}
+
+func NewPsqlTagRepository(db *pg.DB) domain.TagRepository {
+	return &psqlTagRepository{DB: db}
+}
+
+func (t *psqlTagRepository) Fetch(ctx context.Context) (res []domain.TagCount, err error) {
+	// Only published articles count, the same ones the public can browse by tag.
+	_, err = t.DB.QueryContext(ctx, &res, `
+		SELECT tag.id, tag.name, tag.slug, tag.created_at, tag.updated_at, count(article.id) AS articles
+		FROM tags AS tag
+		LEFT JOIN article_tags ON article_tags.tag_id = tag.id
+		LEFT JOIN articles AS article ON article.id = article_tags.article_id
+			AND article.deleted_at IS NULL AND article.status = ?
+		GROUP BY tag.id
+		ORDER BY articles DESC, tag.name ASC`,
+		domain.ArticleStatusPublished)
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return res, nil
+}
+
+func (t *psqlTagRepository) FindBySlug(ctx context.Context, slug string) (tag *domain.Tag, err error) {
+	tag = new(domain.Tag)
+	if err := t.DB.ModelContext(ctx, tag).Where("slug = ?", slug).First(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return tag, nil
+}
+
+func (t *psqlTagRepository) Ensure(ctx context.Context, tags []domain.Tag) (res []domain.Tag, err error) {
+	if len(tags) == 0 {
+		return []domain.Tag{}, nil
+	}
+
+	// A tag created concurrently under the same slug wins, the select below picks it up.
+	db := database.Conn(ctx, t.DB)
+	if _, err := db.ModelContext(ctx, &tags).OnConflict("(slug) DO NOTHING").Insert(); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+
+	slugs := make([]string, len(tags))
+	for i, tag := range tags {
+		slugs[i] = tag.Slug
+	}
+
+	err = db.ModelContext(ctx, &res).Where("slug IN (?)", pg.In(slugs)).Order("name ASC").Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+	return res, nil
+}
+
+func (t *psqlTagRepository) SetArticleTags(ctx context.Context, articleID uuid.UUID, tagIDs []uuid.UUID) error {
+	err := database.RunInTransaction(ctx, database.Conn(ctx, t.DB), func(tx *pg.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = ?", articleID); err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+
+		rows := make([]domain.ArticleTag, len(tagIDs))
+		for i, tagID := range tagIDs {
+			rows[i] = domain.ArticleTag{ArticleID: articleID, TagID: tagID}
+		}
+		_, err := tx.ModelContext(ctx, &rows).Insert()
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return database.TranslateError(err, nil)
+	}
+	return nil
+}
+
+// articleTag is a tag together with the article it was loaded for.
+type articleTag struct {
+	domain.Tag
+	ArticleID uuid.UUID `pg:"article_id"`
+}
+
+func (t *psqlTagRepository) FetchByArticles(ctx context.Context, articleIDs []uuid.UUID) (res map[uuid.UUID][]domain.Tag, err error) {
+	res = make(map[uuid.UUID][]domain.Tag, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []articleTag
+	_, err = t.DB.QueryContext(ctx, &rows, `
+		SELECT tag.id, tag.name, tag.slug, tag.created_at, tag.updated_at, article_tags.article_id
+		FROM article_tags
+		JOIN tags AS tag ON tag.id = article_tags.tag_id
+		WHERE article_tags.article_id IN (?)
+		ORDER BY tag.name ASC`,
+		pg.In(articleIDs))
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, database.TranslateError(err, nil)
+	}
+
+	for _, row := range rows {
+		res[row.ArticleID] = append(res[row.ArticleID], row.Tag)
+	}
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"go-boilerplate/domain"
+	"time"
+)
+
+type tagUsecase struct {
+	TagRepo        domain.TagRepository
+	ArticleUsecase domain.ArticleUsecase
+	ContextTimeout time.Duration
+}
+
+func (t *tagUsecase) FetchTags(ctx context.Context) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.ContextTimeout)
+	defer cancel()
+
+	tags, err := t.TagRepo.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []domain.TagCount{}
+	}
+	return tags, nil
+}
+
+func (t *tagUsecase) FetchArticles(ctx context.Context, slug string, filter *domain.ArticleFilter) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.ContextTimeout)
+	defer cancel()
+
+	tag, err := t.TagRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Tag = tag.Slug
+	filter.Status = domain.ArticleStatusPublished
+	return t.ArticleUsecase.FetchArticles(ctx, filter)
+}
+
+func NewTagUsecase(tagRepo domain.TagRepository, articleUsecase domain.ArticleUsecase, duration time.Duration) domain.TagUsecase {
+	return &tagUsecase{
+		TagRepo:        tagRepo,
+		ArticleUsecase: articleUsecase,
+		ContextTimeout: duration,
+	}
+}
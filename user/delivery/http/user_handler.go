@@ -3,10 +3,10 @@ package http
 import (
 	"context"
 	"errors"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/thedevsaddam/govalidator"
 	"go-boilerplate/domain"
-	"go-boilerplate/helper"
 	"go-boilerplate/middleware"
 	"net/http"
 	"strconv"
@@ -17,17 +17,28 @@ type userHandler struct {
 	userUsecase domain.UserUseCase
 }
 
-func NewUserHandler(e *echo.Echo, UserUsecase domain.UserUseCase) {
+func NewUserHandler(e *echo.Echo, UserUsecase domain.UserUseCase, customMiddleware *middleware.Middleware) {
 	handler := &userHandler{
 		userUsecase: UserUsecase,
 	}
 	user := e.Group("/user")
-	customMiddleware := middleware.Init()
 
 	user.POST("/register", handler.RegisterHandler)
 	user.POST("/login", handler.LoginHandler)
+	user.POST("/token/refresh", handler.RefreshTokenHandler)
+	user.POST("/logout", handler.LogoutHandler, customMiddleware.Auth)
+	user.POST("/password/forgot", handler.ForgotPasswordHandler)
+	user.POST("/password/reset", handler.ResetPasswordHandler)
+	user.GET("/verify/:token", handler.VerifyEmailHandler)
+	user.POST("/verify/resend", handler.ResendVerificationHandler, customMiddleware.Auth)
 	user.GET("/profile", handler.ProfileHandler, customMiddleware.Auth)
-	user.GET("/fetch", handler.UsersHandler)
+	user.PUT("/profile", handler.UpdateProfileHandler, customMiddleware.Auth)
+	user.PUT("/password", handler.ChangePasswordHandler, customMiddleware.Auth)
+	user.DELETE("/account", handler.DeleteAccountHandler, customMiddleware.Auth)
+	user.GET("/fetch", handler.UsersHandler, customMiddleware.Auth, customMiddleware.RequirePermission(domain.PermissionUsersRead))
+	user.DELETE("/:id", handler.DestroyUserHandler, customMiddleware.Auth, customMiddleware.RequirePermission(domain.PermissionUsersDelete))
+	user.POST("/:id/restore", handler.RestoreUserHandler, customMiddleware.Auth, customMiddleware.RequirePermission(domain.PermissionUsersDelete))
+	user.DELETE("/:id/purge", handler.PurgeUserHandler, customMiddleware.Auth, customMiddleware.RequirePermission(domain.PermissionUsersDelete))
 }
 
 func (u userHandler) UsersHandler(e echo.Context) error {
@@ -42,7 +53,7 @@ func (u userHandler) UsersHandler(e echo.Context) error {
 	res, err := u.userUsecase.Fetch(ctx, limit, offset)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
+		return err
 	}
 
 	return e.JSON(http.StatusOK, map[string]interface{}{
@@ -85,7 +96,7 @@ func (u userHandler) RegisterHandler(e echo.Context) error {
 	err := u.userUsecase.Register(ctx, &usr)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetInternal(err)
+		return err
 	}
 
 	return e.JSON(http.StatusOK, map[string]interface{}{
@@ -123,21 +134,273 @@ func (u userHandler) LoginHandler(e echo.Context) error {
 	res, err := u.userUsecase.Login(ctx, &credential)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusFailedDependency, err.Error()).SetInternal(err)
+		return err
 	}
 
 	return e.JSON(http.StatusOK, res)
 }
 
+func (u userHandler) RefreshTokenHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"refresh_token": []string{"required"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := u.userUsecase.RefreshToken(ctx, e.FormValue("refresh_token"))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, res)
+}
+
+func (u userHandler) LogoutHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.Logout(ctx); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (u userHandler) ForgotPasswordHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"email": []string{"required", "email"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.ForgotPassword(ctx, e.FormValue("email")); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status":  "success",
+		"message": "If the address is registered a password reset link has been sent.",
+	})
+}
+
+func (u userHandler) ResetPasswordHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"email":    []string{"required", "email"},
+		"token":    []string{"required"},
+		"password": []string{"required"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := u.userUsecase.ResetPassword(ctx, e.FormValue("email"), e.FormValue("token"), e.FormValue("password"))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (u userHandler) VerifyEmailHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.VerifyEmail(ctx, e.Param("token")); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (u userHandler) ResendVerificationHandler(e echo.Context) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.ResendVerification(ctx); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
 func (u userHandler) ProfileHandler(e echo.Context) error {
 	ctx := e.Request().Context()
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	claims, err := helper.ParseToken(e)
+
+	profile, err := u.userUsecase.Profile(ctx)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, err).SetInternal(err)
+		return err
 	}
-	profile := claims["user"]
 	return e.JSON(http.StatusOK, profile)
 }
+
+func (u userHandler) UpdateProfileHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"email": []string{"email"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	res, err := u.userUsecase.UpdateProfile(ctx, e.FormValue("name"), e.FormValue("email"))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (u userHandler) ChangePasswordHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"current_password": []string{"required"},
+		"password":         []string{"required"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.ChangePassword(ctx, e.FormValue("current_password"), e.FormValue("password")); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (u userHandler) DeleteAccountHandler(e echo.Context) error {
+	rules := govalidator.MapData{
+		"password": []string{"required"},
+	}
+
+	validate := govalidator.Options{
+		Request: e.Request(),
+		Rules:   rules,
+	}
+
+	if err := govalidator.New(validate).Validate(); len(err) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err).SetInternal(errors.New("invalid parameter"))
+	}
+
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := u.userUsecase.DeleteAccount(ctx, e.FormValue("password")); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
+
+func (u userHandler) DestroyUserHandler(e echo.Context) error {
+	return u.manageUser(e, u.userUsecase.DeleteUser)
+}
+
+func (u userHandler) RestoreUserHandler(e echo.Context) error {
+	return u.manageUser(e, u.userUsecase.RestoreUser)
+}
+
+func (u userHandler) PurgeUserHandler(e echo.Context) error {
+	return u.manageUser(e, u.userUsecase.PurgeUser)
+}
+
+// manageUser runs one of the trash actions against the user in the :id path param.
+func (u userHandler) manageUser(e echo.Context, action func(ctx context.Context, id uuid.UUID) error) error {
+	ctx := e.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	userID, err := uuid.Parse(e.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	if err := action(ctx, userID); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, map[string]interface{}{
+		"status": "success",
+	})
+}
@@ -0,0 +1,460 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	_articleMemoryRepository "go-boilerplate/article/repository/memory"
+	"go-boilerplate/domain"
+	"go-boilerplate/middleware"
+	_userHttpDelivery "go-boilerplate/user/delivery/http"
+	_userMemoryRepository "go-boilerplate/user/repository/memory"
+	"go-boilerplate/user/usecase"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const password = "secret-password"
+
+func TestMain(m *testing.M) {
+	viper.Set("JWT_SECRET", "test-secret")
+	viper.Set("JWT_EXPIRED_TOKEN_DURATION", 15)
+	viper.Set("APP_URL", "http://localhost")
+	logrus.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+type mailer struct {
+	mu   sync.Mutex
+	sent []domain.Mail
+}
+
+func (m *mailer) Send(ctx context.Context, mail *domain.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, *mail)
+	return nil
+}
+
+// token pulls the token out of the last mail sent to to, pattern has to capture it.
+func (m *mailer) token(t *testing.T, to string, pattern *regexp.Regexp) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To == to {
+			if match := pattern.FindStringSubmatch(m.sent[i].Body); match != nil {
+				return match[1]
+			}
+		}
+	}
+	t.Fatalf("no token mailed to %s", to)
+	return ""
+}
+
+var (
+	verificationToken = regexp.MustCompile(`/user/verify/(\S+)`)
+	resetToken        = regexp.MustCompile(`Reset token: (\S+)`)
+)
+
+// roleRepository grants permissions per user, it only implements what the user usecase uses.
+type roleRepository struct {
+	domain.RoleRepository
+	permissions map[uuid.UUID][]string
+}
+
+func (r roleRepository) FindByUser(ctx context.Context, userID uuid.UUID) (roles, permissions []string, err error) {
+	if granted, ok := r.permissions[userID]; ok {
+		return []string{"admin"}, granted, nil
+	}
+	return []string{}, []string{}, nil
+}
+
+type server struct {
+	echo   *echo.Echo
+	users  domain.UserRepository
+	roles  roleRepository
+	mailer *mailer
+}
+
+func newServer() *server {
+	s := &server{
+		echo:   echo.New(),
+		users:  _userMemoryRepository.NewMemoryUserRepository(),
+		roles:  roleRepository{permissions: map[uuid.UUID][]string{}},
+		mailer: &mailer{},
+	}
+	tokens := _userMemoryRepository.NewMemoryRefreshTokenRepository()
+	customMiddleware := middleware.Init(s.users, tokens)
+	s.echo.HTTPErrorHandler = customMiddleware.ErrorHandler
+
+	userUsecase := usecase.NewUserUsecase(s.users, tokens, _userMemoryRepository.NewMemoryPasswordResetRepository(),
+		s.roles, _articleMemoryRepository.NewMemoryArticleRepository(), s.mailer, _userMemoryRepository.NewMemoryTransactor(), time.Second)
+	_userHttpDelivery.NewUserHandler(s.echo, userUsecase, customMiddleware)
+	return s
+}
+
+// do sends form as the request body, or as the query string for GET and DELETE requests
+// since their bodies are not parsed.
+func (s *server) do(method, path string, form url.Values, token string) *httptest.ResponseRecorder {
+	var body *strings.Reader
+	if method == http.MethodGet || method == http.MethodDelete {
+		if len(form) > 0 {
+			path += "?" + form.Encode()
+		}
+		body = strings.NewReader("")
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+// register signs up email and grants it permissions before the first login.
+func (s *server) register(t *testing.T, email string, permissions ...string) uuid.UUID {
+	t.Helper()
+	rec := s.do(http.MethodPost, "/user/register", url.Values{"name": {"Jane"}, "email": {email}, "password": {password}}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("register %s: %d %s", email, rec.Code, rec.Body)
+	}
+
+	var res struct {
+		Data domain.User `json:"data"`
+	}
+	decode(t, rec, &res)
+	if len(permissions) > 0 {
+		s.roles.permissions[res.Data.ID] = permissions
+	}
+	return res.Data.ID
+}
+
+func (s *server) login(t *testing.T, email, pass string) (access, refresh string) {
+	t.Helper()
+	rec := s.do(http.MethodPost, "/user/login", url.Values{"email": {email}, "password": {pass}}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s: %d %s", email, rec.Code, rec.Body)
+	}
+
+	var res map[string]interface{}
+	decode(t, rec, &res)
+	return res["access_token"].(string), res["refresh_token"].(string)
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+}
+
+type request struct {
+	name   string
+	method string
+	path   string
+	form   url.Values
+	token  string
+	want   int
+	// wantBody maps dotted paths into the JSON response, e.g. error.code, to the values expected there.
+	wantBody map[string]string
+}
+
+func run(t *testing.T, s *server, tests []request) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.do(tt.method, tt.path, tt.form, tt.token)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+			}
+			checkBody(t, rec, tt.wantBody)
+		})
+	}
+}
+
+func checkBody(t *testing.T, rec *httptest.ResponseRecorder, want map[string]string) {
+	t.Helper()
+	if len(want) == 0 {
+		return
+	}
+
+	var body interface{}
+	decode(t, rec, &body)
+	for path, value := range want {
+		if got := field(body, path); got != value {
+			t.Errorf("%s = %s, want %s", path, got, value)
+		}
+	}
+}
+
+// field formats the value at a dotted path of a decoded JSON document, list elements
+// are picked by their index.
+func field(value interface{}, path string) string {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "<missing>"
+			}
+			value = v[i]
+		default:
+			return "<missing>"
+		}
+	}
+	if value == nil {
+		return "<missing>"
+	}
+	return fmt.Sprint(value)
+}
+
+func TestRegisterHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "taken@example.com")
+
+	run(t, s, []request{
+		{name: "new account", method: http.MethodPost, path: "/user/register",
+			form: url.Values{"name": {"Jane"}, "email": {"jane@example.com"}, "password": {password}}, want: http.StatusOK,
+			wantBody: map[string]string{"data.email": "jane@example.com", "data.name": "Jane"}},
+		{name: "taken email", method: http.MethodPost, path: "/user/register",
+			form: url.Values{"name": {"Jane"}, "email": {"taken@example.com"}, "password": {password}}, want: http.StatusConflict,
+			wantBody: map[string]string{"error.code": "email_taken"}},
+		{name: "missing fields", method: http.MethodPost, path: "/user/register",
+			form: url.Values{"name": {"Jane"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "validation_failed"}},
+	})
+}
+
+func TestLoginHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+
+	run(t, s, []request{
+		{name: "valid credentials", method: http.MethodPost, path: "/user/login",
+			form: url.Values{"email": {"jane@example.com"}, "password": {password}}, want: http.StatusOK},
+		{name: "wrong password", method: http.MethodPost, path: "/user/login",
+			form: url.Values{"email": {"jane@example.com"}, "password": {"wrong"}}, want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "invalid_credentials"}},
+		{name: "missing password", method: http.MethodPost, path: "/user/login",
+			form: url.Values{"email": {"jane@example.com"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "validation_failed"}},
+	})
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+	_, refresh := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "valid token", method: http.MethodPost, path: "/user/token/refresh",
+			form: url.Values{"refresh_token": {refresh}}, want: http.StatusOK},
+		{name: "replayed token", method: http.MethodPost, path: "/user/token/refresh",
+			form: url.Values{"refresh_token": {refresh}}, want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "refresh_token_reused"}},
+		{name: "unknown token", method: http.MethodPost, path: "/user/token/refresh",
+			form: url.Values{"refresh_token": {"unknown"}}, want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "invalid_refresh_token"}},
+		{name: "missing token", method: http.MethodPost, path: "/user/token/refresh", want: http.StatusUnprocessableEntity},
+	})
+}
+
+func TestLogoutHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+	access, _ := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "signed in", method: http.MethodPost, path: "/user/logout", token: access, want: http.StatusOK},
+		{name: "revoked token", method: http.MethodPost, path: "/user/logout", token: access, want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "unauthorized"}},
+		{name: "no token", method: http.MethodPost, path: "/user/logout", want: http.StatusUnauthorized,
+			wantBody: map[string]string{"error.code": "unauthorized"}},
+		{name: "garbage token", method: http.MethodPost, path: "/user/logout", token: "garbage", want: http.StatusUnauthorized},
+	})
+}
+
+func TestPasswordResetHandlers(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+
+	run(t, s, []request{
+		{name: "forgot registered address", method: http.MethodPost, path: "/user/password/forgot",
+			form: url.Values{"email": {"jane@example.com"}}, want: http.StatusOK},
+		{name: "forgot unknown address", method: http.MethodPost, path: "/user/password/forgot",
+			form: url.Values{"email": {"john@example.com"}}, want: http.StatusOK},
+		{name: "forgot malformed address", method: http.MethodPost, path: "/user/password/forgot",
+			form: url.Values{"email": {"jane"}}, want: http.StatusUnprocessableEntity},
+	})
+
+	token := s.mailer.token(t, "jane@example.com", resetToken)
+	run(t, s, []request{
+		{name: "reset with a wrong token", method: http.MethodPost, path: "/user/password/reset",
+			form: url.Values{"email": {"jane@example.com"}, "token": {"wrong"}, "password": {"new-password"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_reset_token"}},
+		{name: "reset", method: http.MethodPost, path: "/user/password/reset",
+			form: url.Values{"email": {"jane@example.com"}, "token": {token}, "password": {"new-password"}}, want: http.StatusOK},
+		{name: "reset twice", method: http.MethodPost, path: "/user/password/reset",
+			form: url.Values{"email": {"jane@example.com"}, "token": {token}, "password": {"new-password"}}, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_reset_token"}},
+		{name: "reset without a token", method: http.MethodPost, path: "/user/password/reset",
+			form: url.Values{"email": {"jane@example.com"}, "password": {"new-password"}}, want: http.StatusUnprocessableEntity},
+	})
+
+	s.login(t, "jane@example.com", "new-password")
+}
+
+func TestVerificationHandlers(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+	access, _ := s.login(t, "jane@example.com", password)
+	token := s.mailer.token(t, "jane@example.com", verificationToken)
+
+	run(t, s, []request{
+		{name: "resend within the cooldown", method: http.MethodPost, path: "/user/verify/resend", token: access, want: http.StatusTooManyRequests,
+			wantBody: map[string]string{"error.code": "verification_cooldown"}},
+		{name: "resend anonymously", method: http.MethodPost, path: "/user/verify/resend", want: http.StatusUnauthorized},
+		{name: "verify with a bad token", method: http.MethodGet, path: "/user/verify/garbage", want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_verification_token"}},
+		{name: "verify", method: http.MethodGet, path: "/user/verify/" + token, want: http.StatusOK},
+		{name: "verify twice", method: http.MethodGet, path: "/user/verify/" + token, want: http.StatusOK},
+		{name: "resend once verified", method: http.MethodPost, path: "/user/verify/resend", token: access, want: http.StatusConflict,
+			wantBody: map[string]string{"error.code": "email_already_verified"}},
+	})
+}
+
+func TestProfileHandlers(t *testing.T) {
+	s := newServer()
+	s.register(t, "taken@example.com")
+	s.register(t, "jane@example.com")
+	access, _ := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "show", method: http.MethodGet, path: "/user/profile", token: access, want: http.StatusOK,
+			wantBody: map[string]string{"email": "jane@example.com", "name": "Jane"}},
+		{name: "show anonymously", method: http.MethodGet, path: "/user/profile", want: http.StatusUnauthorized},
+		{name: "rename", method: http.MethodPut, path: "/user/profile",
+			form: url.Values{"name": {"Janet"}}, token: access, want: http.StatusOK,
+			wantBody: map[string]string{"data.name": "Janet"}},
+		{name: "taken email", method: http.MethodPut, path: "/user/profile",
+			form: url.Values{"email": {"taken@example.com"}}, token: access, want: http.StatusConflict,
+			wantBody: map[string]string{"error.code": "email_taken"}},
+		{name: "malformed email", method: http.MethodPut, path: "/user/profile",
+			form: url.Values{"email": {"janet"}}, token: access, want: http.StatusUnprocessableEntity},
+	})
+
+	rec := s.do(http.MethodGet, "/user/profile", nil, access)
+	var profile domain.User
+	decode(t, rec, &profile)
+	if profile.Name != "Janet" {
+		t.Errorf("name = %q, want %q", profile.Name, "Janet")
+	}
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+	access, _ := s.login(t, "jane@example.com", password)
+	other, _ := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "wrong current password", method: http.MethodPut, path: "/user/password",
+			form: url.Values{"current_password": {"wrong"}, "password": {"new-password"}}, token: access, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_password"}},
+		{name: "missing new password", method: http.MethodPut, path: "/user/password",
+			form: url.Values{"current_password": {password}}, token: access, want: http.StatusUnprocessableEntity},
+		{name: "change", method: http.MethodPut, path: "/user/password",
+			form: url.Values{"current_password": {password}, "password": {"new-password"}}, token: access, want: http.StatusOK},
+		{name: "other session is signed out", method: http.MethodGet, path: "/user/profile", token: other, want: http.StatusUnauthorized},
+		{name: "current session stays", method: http.MethodGet, path: "/user/profile", token: access, want: http.StatusOK},
+	})
+}
+
+func TestDeleteAccountHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "jane@example.com")
+	access, _ := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "wrong password", method: http.MethodDelete, path: "/user/account",
+			form: url.Values{"password": {"wrong"}}, token: access, want: http.StatusUnprocessableEntity,
+			wantBody: map[string]string{"error.code": "invalid_password"}},
+		{name: "missing password", method: http.MethodDelete, path: "/user/account", token: access, want: http.StatusUnprocessableEntity},
+		{name: "delete", method: http.MethodDelete, path: "/user/account",
+			form: url.Values{"password": {password}}, token: access, want: http.StatusOK},
+		{name: "signed out afterwards", method: http.MethodGet, path: "/user/profile", token: access, want: http.StatusUnauthorized},
+	})
+}
+
+func TestUsersHandler(t *testing.T) {
+	s := newServer()
+	s.register(t, "admin@example.com", domain.PermissionUsersRead)
+	s.register(t, "jane@example.com")
+	admin, _ := s.login(t, "admin@example.com", password)
+	jane, _ := s.login(t, "jane@example.com", password)
+
+	run(t, s, []request{
+		{name: "with permission", method: http.MethodGet, path: "/user/fetch",
+			form: url.Values{"limit": {"1"}}, token: admin, want: http.StatusOK,
+			wantBody: map[string]string{"data.users.0.email": "admin@example.com", "data.row": "1"}},
+		{name: "without permission", method: http.MethodGet, path: "/user/fetch", token: jane, want: http.StatusForbidden,
+			wantBody: map[string]string{"error.code": "forbidden"}},
+		{name: "anonymous", method: http.MethodGet, path: "/user/fetch", want: http.StatusUnauthorized},
+	})
+
+	var res struct {
+		Data struct {
+			Users []domain.User `json:"users"`
+		} `json:"data"`
+	}
+	decode(t, s.do(http.MethodGet, "/user/fetch", nil, admin), &res)
+	if len(res.Data.Users) != 2 {
+		t.Errorf("fetched %d users, want 2", len(res.Data.Users))
+	}
+}
+
+func TestManageUserHandlers(t *testing.T) {
+	s := newServer()
+	s.register(t, "admin@example.com", domain.PermissionUsersDelete)
+	janeID := s.register(t, "jane@example.com")
+	admin, _ := s.login(t, "admin@example.com", password)
+	jane, _ := s.login(t, "jane@example.com", password)
+	path := "/user/" + janeID.String()
+
+	run(t, s, []request{
+		{name: "delete without permission", method: http.MethodDelete, path: path, token: jane, want: http.StatusForbidden},
+		{name: "purge a live user", method: http.MethodDelete, path: path + "/purge", token: admin, want: http.StatusNotFound},
+		{name: "delete", method: http.MethodDelete, path: path, token: admin, want: http.StatusOK},
+		{name: "delete twice", method: http.MethodDelete, path: path, token: admin, want: http.StatusNotFound,
+			wantBody: map[string]string{"error.code": "not_found"}},
+		{name: "restore", method: http.MethodPost, path: path + "/restore", token: admin, want: http.StatusOK},
+		{name: "restore a live user", method: http.MethodPost, path: path + "/restore", token: admin, want: http.StatusNotFound},
+		{name: "delete again", method: http.MethodDelete, path: path, token: admin, want: http.StatusOK},
+		{name: "purge", method: http.MethodDelete, path: path + "/purge", token: admin, want: http.StatusOK},
+		{name: "restore a purged user", method: http.MethodPost, path: path + "/restore", token: admin, want: http.StatusNotFound},
+		{name: "malformed id", method: http.MethodDelete, path: "/user/not-a-uuid", token: admin, want: http.StatusNotFound,
+			wantBody: map[string]string{"error.code": "not_found"}},
+	})
+}
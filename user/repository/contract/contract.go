@@ -0,0 +1,279 @@
+// Package contract checks that a domain.UserRepository behaves the way the usecases rely
+// on, whatever it is backed by. Implementations call UserRepository from their tests.
+package contract
+
+import (
+	"context"
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+const password = "secret-password"
+
+// UserRepository runs the contract, every subtest gets a fresh repository from newRepository.
+func UserRepository(t *testing.T, newRepository func(t *testing.T) domain.UserRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo domain.UserRepository)
+	}{
+		{"CreateUser stores the user", createUser},
+		{"CreateUser rejects a taken email", createUserTakenEmail},
+		{"Attempt checks the password", attempt},
+		{"Find and FindBy miss unknown users", findMissing},
+		{"FindBy looks up by column", findBy},
+		{"Update writes the non-zero fields", update},
+		{"Update ignores unknown users", updateUnknown},
+		{"ChangeEmail resets verification", changeEmail},
+		{"ChangeEmail misses unknown users", changeEmailUnknown},
+		{"EmailTaken counts trashed users", emailTaken},
+		{"Delete, Restore and ForceDelete follow the trash", trash},
+		{"Fetch pages through live users", fetch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepository(t))
+		})
+	}
+}
+
+// create stores a user with a bcrypt hashed password, ages are whole seconds since
+// PostgreSQL keeps no more.
+func create(t *testing.T, repo domain.UserRepository, email string, age time.Duration) *domain.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Now().Truncate(time.Second).Add(-age)
+	usr := &domain.User{ID: uuid.New(), Name: "Jane", Email: email, Password: string(hash), CreatedAt: at, UpdatedAt: at}
+	if err := repo.CreateUser(context.Background(), usr); err != nil {
+		t.Fatalf("create %s: %v", email, err)
+	}
+	return usr
+}
+
+func find(t *testing.T, repo domain.UserRepository, id uuid.UUID) *domain.User {
+	t.Helper()
+	usr, err := repo.Find(context.Background(), id)
+	if err != nil {
+		t.Fatalf("find %v: %v", id, err)
+	}
+	return usr
+}
+
+func wantErr(t *testing.T, action string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s: err = %v, want %v", action, err, want)
+	}
+}
+
+func createUser(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+
+	stored := find(t, repo, usr.ID)
+	if stored.Name != usr.Name || stored.Email != usr.Email || stored.Password != usr.Password {
+		t.Errorf("stored %+v, want %+v", stored, usr)
+	}
+	if !stored.DeletedAt.IsZero() || !stored.EmailVerifiedAt.IsZero() {
+		t.Errorf("new user is trashed or verified: %+v", stored)
+	}
+}
+
+func createUserTakenEmail(t *testing.T, repo domain.UserRepository) {
+	create(t, repo, "jane@example.com", 0)
+
+	err := repo.CreateUser(context.Background(), &domain.User{ID: uuid.New(), Name: "Janet", Email: "jane@example.com", Password: "x"})
+	wantErr(t, "CreateUser", err, domain.ErrEmailTaken)
+}
+
+func attempt(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+	ctx := context.Background()
+
+	found, err := repo.Attempt(ctx, &domain.Credential{Email: "jane@example.com", Password: password})
+	if err != nil || found.ID != usr.ID {
+		t.Fatalf("valid credentials: got %v, %v", found, err)
+	}
+
+	if _, err := repo.Attempt(ctx, &domain.Credential{Email: "jane@example.com", Password: "wrong"}); err == nil {
+		t.Error("wrong password was accepted")
+	}
+
+	_, err = repo.Attempt(ctx, &domain.Credential{Email: "john@example.com", Password: password})
+	wantErr(t, "unknown email", err, domain.ErrNotFound)
+}
+
+func findMissing(t *testing.T, repo domain.UserRepository) {
+	ctx := context.Background()
+
+	_, err := repo.Find(ctx, uuid.New())
+	wantErr(t, "Find", err, domain.ErrNotFound)
+
+	_, err = repo.FindBy(ctx, "email", "nobody@example.com")
+	wantErr(t, "FindBy", err, domain.ErrNotFound)
+}
+
+func findBy(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+	create(t, repo, "john@example.com", 0)
+	ctx := context.Background()
+
+	for _, key := range []string{"id", "email"} {
+		value := usr.Email
+		if key == "id" {
+			value = usr.ID.String()
+		}
+
+		found, err := repo.FindBy(ctx, key, value)
+		if err != nil || found.ID != usr.ID {
+			t.Errorf("FindBy(%q): got %v, %v", key, found, err)
+		}
+	}
+}
+
+func update(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+
+	err := repo.Update(context.Background(), &domain.User{ID: usr.ID, Name: "Janet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := find(t, repo, usr.ID)
+	if stored.Name != "Janet" || stored.Email != usr.Email || stored.Password != usr.Password {
+		t.Errorf("after update got %s <%s>, want Janet <%s> with the password kept", stored.Name, stored.Email, usr.Email)
+	}
+}
+
+func updateUnknown(t *testing.T, repo domain.UserRepository) {
+	id := uuid.New()
+	if err := repo.Update(context.Background(), &domain.User{ID: id, Name: "Nobody"}); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+
+	_, err := repo.Find(context.Background(), id)
+	wantErr(t, "Find after update", err, domain.ErrNotFound)
+}
+
+func changeEmail(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+	usr.EmailVerifiedAt = pg.NullTime{Time: time.Now()}
+	if err := repo.Update(context.Background(), usr); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.ChangeEmail(context.Background(), usr.ID, "janet@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := find(t, repo, usr.ID)
+	if stored.Email != "janet@example.com" || !stored.EmailVerifiedAt.IsZero() || stored.EmailVerificationSentAt.IsZero() {
+		t.Errorf("after ChangeEmail got <%s> verified at %v, sent at %v", stored.Email, stored.EmailVerifiedAt, stored.EmailVerificationSentAt)
+	}
+}
+
+func changeEmailUnknown(t *testing.T, repo domain.UserRepository) {
+	err := repo.ChangeEmail(context.Background(), uuid.New(), "janet@example.com")
+	wantErr(t, "ChangeEmail", err, domain.ErrNotFound)
+}
+
+func emailTaken(t *testing.T, repo domain.UserRepository) {
+	jane := create(t, repo, "jane@example.com", 0)
+	gone := create(t, repo, "gone@example.com", 0)
+	if err := repo.Delete(context.Background(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		email    string
+		exceptID uuid.UUID
+		want     bool
+	}{
+		{"jane@example.com", uuid.Nil, true},
+		{"jane@example.com", jane.ID, false},
+		{"gone@example.com", uuid.Nil, true},
+		{"free@example.com", uuid.Nil, false},
+	}
+
+	for _, tt := range tests {
+		taken, err := repo.EmailTaken(context.Background(), tt.email, tt.exceptID)
+		if err != nil || taken != tt.want {
+			t.Errorf("EmailTaken(%s, %v) = %v, %v, want %v", tt.email, tt.exceptID, taken, err, tt.want)
+		}
+	}
+}
+
+func trash(t *testing.T, repo domain.UserRepository) {
+	usr := create(t, repo, "jane@example.com", 0)
+	ctx := context.Background()
+
+	wantErr(t, "Restore a live user", repo.Restore(ctx, usr.ID), domain.ErrNotFound)
+	wantErr(t, "ForceDelete a live user", repo.ForceDelete(ctx, usr.ID), domain.ErrNotFound)
+
+	wantErr(t, "Delete", repo.Delete(ctx, usr.ID), nil)
+	_, err := repo.Find(ctx, usr.ID)
+	wantErr(t, "Find a trashed user", err, domain.ErrNotFound)
+	_, err = repo.FindBy(ctx, "email", usr.Email)
+	wantErr(t, "FindBy a trashed user", err, domain.ErrNotFound)
+	wantErr(t, "Delete twice", repo.Delete(ctx, usr.ID), domain.ErrNotFound)
+
+	wantErr(t, "Restore", repo.Restore(ctx, usr.ID), nil)
+	find(t, repo, usr.ID)
+
+	wantErr(t, "Delete again", repo.Delete(ctx, usr.ID), nil)
+	wantErr(t, "ForceDelete", repo.ForceDelete(ctx, usr.ID), nil)
+	wantErr(t, "Restore a purged user", repo.Restore(ctx, usr.ID), domain.ErrNotFound)
+	wantErr(t, "ForceDelete twice", repo.ForceDelete(ctx, usr.ID), domain.ErrNotFound)
+
+	taken, err := repo.EmailTaken(ctx, usr.Email, uuid.Nil)
+	if err != nil || taken {
+		t.Errorf("purged user still holds the address: %v, %v", taken, err)
+	}
+}
+
+func fetch(t *testing.T, repo domain.UserRepository) {
+	oldest := create(t, repo, "oldest@example.com", 3*time.Hour)
+	middle := create(t, repo, "middle@example.com", 2*time.Hour)
+	newest := create(t, repo, "newest@example.com", time.Hour)
+	gone := create(t, repo, "gone@example.com", 4*time.Hour)
+	if err := repo.Delete(context.Background(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          []uuid.UUID
+	}{
+		{0, 0, []uuid.UUID{oldest.ID, middle.ID, newest.ID}},
+		{2, 0, []uuid.UUID{oldest.ID, middle.ID}},
+		{2, 2, []uuid.UUID{newest.ID}},
+		{2, 5, nil},
+	}
+
+	for _, tt := range tests {
+		users, err := repo.Fetch(context.Background(), tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("Fetch(%d, %d): %v", tt.limit, tt.offset, err)
+		}
+		if len(users) != len(tt.want) {
+			t.Errorf("Fetch(%d, %d) returned %d users, want %d", tt.limit, tt.offset, len(users), len(tt.want))
+			continue
+		}
+		for i, usr := range users {
+			if usr.ID != tt.want[i] {
+				t.Errorf("Fetch(%d, %d)[%d] = %s, want %v", tt.limit, tt.offset, i, usr.Email, tt.want[i])
+			}
+			if usr.Password != "" {
+				t.Errorf("Fetch returned the password hash of %s", usr.Email)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"go-boilerplate/domain"
+	"sync"
+)
+
+type memoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	resets []domain.PasswordReset
+}
+
+func NewMemoryPasswordResetRepository() domain.PasswordResetRepository {
+	return &memoryPasswordResetRepository{}
+}
+
+func (p *memoryPasswordResetRepository) Create(ctx context.Context, reset *domain.PasswordReset) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.resets = append(p.resets, *reset)
+	return nil
+}
+
+func (p *memoryPasswordResetRepository) FindByEmail(ctx context.Context, email string) (reset *domain.PasswordReset, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, stored := range p.resets {
+		if stored.Email != email {
+			continue
+		}
+		if reset == nil || stored.CreatedAt.After(reset.CreatedAt) {
+			stored := stored
+			reset = &stored
+		}
+	}
+	if reset == nil {
+		return nil, domain.ErrNotFound
+	}
+	return reset, nil
+}
+
+func (p *memoryPasswordResetRepository) DeleteByEmail(ctx context.Context, email string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	kept := p.resets[:0]
+	for _, stored := range p.resets {
+		if stored.Email != email {
+			kept = append(kept, stored)
+		}
+	}
+	p.resets = kept
+	return nil
+}
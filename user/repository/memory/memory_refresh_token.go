@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"sync"
+	"time"
+)
+
+type memoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]domain.RefreshToken
+}
+
+func NewMemoryRefreshTokenRepository() domain.RefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: make(map[uuid.UUID]domain.RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.ID]; ok {
+		return domain.ErrConflict
+	}
+	for _, stored := range r.tokens {
+		if stored.TokenHash == token.TokenHash {
+			return domain.ErrConflict
+		}
+	}
+
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (token *domain.RefreshToken, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.TokenHash == hash {
+			return &stored, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (ok bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, found := r.tokens[id]
+	if !found || !token.UsedAt.IsZero() || !token.RevokedAt.IsZero() {
+		return false, nil
+	}
+
+	token.UsedAt = pg.NullTime{Time: time.Now()}
+	r.tokens[id] = token
+	return true, nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revoke(func(token domain.RefreshToken) bool {
+		return token.FamilyID == familyID
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	r.revoke(func(token domain.RefreshToken) bool {
+		return token.UserID == userID
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, accessTokenID uuid.UUID) error {
+	current := r.families(accessTokenID)
+	r.revoke(func(token domain.RefreshToken) bool {
+		return token.UserID == userID && !current[token.FamilyID]
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeByAccessToken(ctx context.Context, accessTokenID uuid.UUID) error {
+	families := r.families(accessTokenID)
+	r.revoke(func(token domain.RefreshToken) bool {
+		return families[token.FamilyID]
+	})
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) IsAccessTokenRevoked(ctx context.Context, accessTokenID uuid.UUID) (revoked bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.AccessTokenID == accessTokenID && !token.RevokedAt.IsZero() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// families returns the sessions tokens issued along with accessTokenID belong to.
+func (r *memoryRefreshTokenRepository) families(accessTokenID uuid.UUID) map[uuid.UUID]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make(map[uuid.UUID]bool)
+	for _, token := range r.tokens {
+		if token.AccessTokenID == accessTokenID {
+			res[token.FamilyID] = true
+		}
+	}
+	return res
+}
+
+// revoke stamps every live token matching match as revoked.
+func (r *memoryRefreshTokenRepository) revoke(match func(token domain.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.RevokedAt.IsZero() && match(token) {
+			token.RevokedAt = pg.NullTime{Time: now}
+			r.tokens[id] = token
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"context"
+	"go-boilerplate/domain"
+)
+
+type memoryTransactor struct{}
+
+// NewMemoryTransactor returns a domain.Transactor for the memory repositories. They
+// apply writes right away, so fn simply runs and nothing is rolled back on failure.
+func NewMemoryTransactor() domain.Transactor {
+	return memoryTransactor{}
+}
+
+func (memoryTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
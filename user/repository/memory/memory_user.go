@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"go-boilerplate/domain"
+	"golang.org/x/crypto/bcrypt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryUserRepository keeps users in a map. It answers like psqlUserRepository does,
+// with domain.ErrNotFound for missing users and domain.ErrEmailTaken for taken addresses.
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]domain.User
+}
+
+func NewMemoryUserRepository() domain.UserRepository {
+	return &memoryUserRepository{users: make(map[uuid.UUID]domain.User)}
+}
+
+func (m *memoryUserRepository) Fetch(ctx context.Context, limit, offset int) (res []domain.User, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]domain.User, 0, len(m.users))
+	for _, usr := range m.users {
+		if usr.DeletedAt.IsZero() {
+			// Only the columns the PostgreSQL listing selects.
+			users = append(users, domain.User{ID: usr.ID, Name: usr.Name, Email: usr.Email, CreatedAt: usr.CreatedAt})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+
+	users = page(users, limit, offset)
+	for i := range users {
+		users[i].CreatedAt = time.Time{}
+	}
+	return users, nil
+}
+
+func (m *memoryUserRepository) CreateUser(ctx context.Context, usr *domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[usr.ID]; ok {
+		return domain.ErrConflict
+	}
+	if m.emailTaken(usr.Email, uuid.Nil) {
+		return domain.ErrEmailTaken
+	}
+
+	m.users[usr.ID] = stored(usr)
+	return nil
+}
+
+func (m *memoryUserRepository) Attempt(ctx context.Context, credential *domain.Credential) (user *domain.User, err error) {
+	user, err = m.FindBy(ctx, "email", credential.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credential.Password)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (m *memoryUserRepository) Update(ctx context.Context, usr *domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.users[usr.ID]
+	if !ok || !current.DeletedAt.IsZero() {
+		// An update that matches no row is not an error to PostgreSQL either.
+		return nil
+	}
+
+	if usr.Email != "" && usr.Email != current.Email && m.emailTaken(usr.Email, usr.ID) {
+		return domain.ErrEmailTaken
+	}
+
+	// Zero values are left alone, as UpdateNotZero does.
+	if usr.Name != "" {
+		current.Name = usr.Name
+	}
+	if usr.Email != "" {
+		current.Email = usr.Email
+	}
+	if usr.Password != "" {
+		current.Password = usr.Password
+	}
+	if !usr.EmailVerifiedAt.IsZero() {
+		current.EmailVerifiedAt = usr.EmailVerifiedAt
+	}
+	if !usr.EmailVerificationSentAt.IsZero() {
+		current.EmailVerificationSentAt = usr.EmailVerificationSentAt
+	}
+	if !usr.CreatedAt.IsZero() {
+		current.CreatedAt = usr.CreatedAt
+	}
+	if !usr.UpdatedAt.IsZero() {
+		current.UpdatedAt = usr.UpdatedAt
+	}
+	if !usr.DeletedAt.IsZero() {
+		current.DeletedAt = usr.DeletedAt
+	}
+
+	m.users[usr.ID] = current
+	return nil
+}
+
+func (m *memoryUserRepository) ChangeEmail(ctx context.Context, id uuid.UUID, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usr, ok := m.users[id]
+	if !ok || !usr.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+	if email != usr.Email && m.emailTaken(email, id) {
+		return domain.ErrEmailTaken
+	}
+
+	now := time.Now()
+	usr.Email = email
+	usr.EmailVerifiedAt = pg.NullTime{}
+	usr.EmailVerificationSentAt = pg.NullTime{Time: now}
+	usr.UpdatedAt = now
+	m.users[id] = usr
+	return nil
+}
+
+func (m *memoryUserRepository) EmailTaken(ctx context.Context, email string, exceptID uuid.UUID) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.emailTaken(email, exceptID), nil
+}
+
+// emailTaken looks at trashed users too, their addresses stay reserved. The caller holds the lock.
+func (m *memoryUserRepository) emailTaken(email string, exceptID uuid.UUID) bool {
+	for id, usr := range m.users {
+		if id != exceptID && usr.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usr, ok := m.users[id]
+	if !ok || !usr.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+
+	usr.DeletedAt = pg.NullTime{Time: time.Now()}
+	m.users[id] = usr
+	return nil
+}
+
+func (m *memoryUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usr, ok := m.users[id]
+	if !ok || usr.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+
+	usr.DeletedAt = pg.NullTime{}
+	m.users[id] = usr
+	return nil
+}
+
+func (m *memoryUserRepository) ForceDelete(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usr, ok := m.users[id]
+	if !ok || usr.DeletedAt.IsZero() {
+		return domain.ErrNotFound
+	}
+
+	delete(m.users, id)
+	return nil
+}
+
+func (m *memoryUserRepository) Find(ctx context.Context, id uuid.UUID) (user *domain.User, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usr, ok := m.users[id]
+	if !ok || !usr.DeletedAt.IsZero() {
+		return nil, domain.ErrNotFound
+	}
+	return &usr, nil
+}
+
+func (m *memoryUserRepository) FindBy(ctx context.Context, key, value string) (user *domain.User, err error) {
+	column := strings.TrimSpace(key)
+	if !userColumns[column] {
+		return nil, fmt.Errorf("ERROR #42703 column %q does not exist", column)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *domain.User
+	for _, usr := range m.users {
+		if !usr.DeletedAt.IsZero() || userColumn(&usr, column) != value {
+			continue
+		}
+		// First orders by primary key.
+		if found == nil || usr.ID.String() < found.ID.String() {
+			usr := usr
+			found = &usr
+		}
+	}
+	if found == nil {
+		return nil, domain.ErrNotFound
+	}
+	return found, nil
+}
+
+// userColumns are the columns FindBy can look users up by.
+var userColumns = map[string]bool{
+	"id":    true,
+	"name":  true,
+	"email": true,
+}
+
+func userColumn(usr *domain.User, column string) string {
+	switch column {
+	case "id":
+		return usr.ID.String()
+	case "name":
+		return usr.Name
+	default:
+		return usr.Email
+	}
+}
+
+// stored copies usr the way it ends up in the table, without what is loaded from elsewhere.
+func stored(usr *domain.User) domain.User {
+	row := *usr
+	row.Roles = nil
+	row.Permissions = nil
+	return row
+}
+
+// page cuts out the rows LIMIT and OFFSET would return, a limit of 0 means no limit.
+func page(users []domain.User, limit, offset int) []domain.User {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(users) {
+		return []domain.User{}
+	}
+	users = users[offset:]
+	if limit > 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users
+}
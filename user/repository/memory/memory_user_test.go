@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"go-boilerplate/domain"
+	"go-boilerplate/user/repository/contract"
+	"go-boilerplate/user/repository/memory"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	contract.UserRepository(t, func(t *testing.T) domain.UserRepository {
+		return memory.NewMemoryUserRepository()
+	})
+}
@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+)
+
+type psqlPasswordResetRepository struct {
+	DB *pg.DB
+}
+
+func (p *psqlPasswordResetRepository) Create(ctx context.Context, reset *domain.PasswordReset) error {
+	_, err := p.DB.ModelContext(ctx, reset).Insert()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (p *psqlPasswordResetRepository) FindByEmail(ctx context.Context, email string) (reset *domain.PasswordReset, err error) {
+	reset = new(domain.PasswordReset)
+	err = p.DB.ModelContext(ctx, reset).Where("email = ?", email).Order("created_at DESC").Limit(1).Select()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return reset, nil
+}
+
+func (p *psqlPasswordResetRepository) DeleteByEmail(ctx context.Context, email string) error {
+	_, err := p.DB.ModelContext(ctx, (*domain.PasswordReset)(nil)).Where("email = ?", email).Delete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func NewPsqlPasswordResetRepository(db *pg.DB) domain.PasswordResetRepository {
+	return &psqlPasswordResetRepository{DB: db}
+}
@@ -0,0 +1,121 @@
+package postgresql
+
+import (
+	"context"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	database "go-boilerplate/db/postgresql"
+	"go-boilerplate/domain"
+	"go-boilerplate/logging"
+	"time"
+)
+
+type psqlRefreshTokenRepository struct {
+	DB *pg.DB
+}
+
+func (r *psqlRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	_, err := r.DB.ModelContext(ctx, token).Insert()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *psqlRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (token *domain.RefreshToken, err error) {
+	token = new(domain.RefreshToken)
+	err = r.DB.ModelContext(ctx, token).Where("token_hash = ?", hash).First()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
+	}
+	return token, nil
+}
+
+// MarkUsed only succeeds for a token nobody has rotated yet, so two concurrent refreshes
+// with the same token can not both win.
+func (r *psqlRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (ok bool, err error) {
+	res, err := r.DB.ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("id = ?", id).
+		Where("used_at IS NULL").
+		Where("revoked_at IS NULL").
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return false, translateError(err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+func (r *psqlRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := r.DB.ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// RevokeUser ends every session the user has open.
+func (r *psqlRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := database.Conn(ctx, r.DB).ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *psqlRefreshTokenRepository) RevokeOtherSessions(ctx context.Context, userID, accessTokenID uuid.UUID) error {
+	_, err := r.DB.ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("family_id NOT IN (SELECT family_id FROM refresh_tokens WHERE access_token_id = ?)", accessTokenID).
+		Where("revoked_at IS NULL").
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// RevokeByAccessToken revokes the session the access token was issued for.
+func (r *psqlRefreshTokenRepository) RevokeByAccessToken(ctx context.Context, accessTokenID uuid.UUID) error {
+	_, err := r.DB.ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("family_id IN (SELECT family_id FROM refresh_tokens WHERE access_token_id = ?)", accessTokenID).
+		Where("revoked_at IS NULL").
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *psqlRefreshTokenRepository) IsAccessTokenRevoked(ctx context.Context, accessTokenID uuid.UUID) (revoked bool, err error) {
+	revoked, err = r.DB.ModelContext(ctx, (*domain.RefreshToken)(nil)).
+		Where("access_token_id = ?", accessTokenID).
+		Where("revoked_at IS NOT NULL").
+		Exists()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return false, translateError(err)
+	}
+	return revoked, nil
+}
+
+func NewPsqlRefreshTokenRepository(db *pg.DB) domain.RefreshTokenRepository {
+	return &psqlRefreshTokenRepository{DB: db}
+}
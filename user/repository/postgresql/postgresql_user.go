@@ -2,15 +2,17 @@ package postgresql
 
 import (
 	"context"
-	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/google/uuid"
-	"github.com/sirupsen/logrus"
+	database "go-boilerplate/db/postgresql"
 	"go-boilerplate/domain"
+	"go-boilerplate/logging"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 type psqlUserRepository struct {
-	DB *pg.DB
+	DB orm.DB
 }
 
 func (u *psqlUserRepository) Fetch(ctx context.Context, limit, offset int) (res []domain.User, err error) {
@@ -21,8 +23,8 @@ func (u *psqlUserRepository) Fetch(ctx context.Context, limit, offset int) (res
 		Limit(limit).Offset(offset).Select()
 
 	if err != nil {
-		logrus.Warnln(err)
-		return nil, err
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 	return users, nil
 }
@@ -30,8 +32,8 @@ func (u *psqlUserRepository) Fetch(ctx context.Context, limit, offset int) (res
 func (u *psqlUserRepository) CreateUser(ctx context.Context, usr *domain.User) error {
 	_, err := u.DB.Model(usr).Insert()
 	if err != nil {
-		logrus.Warnln(err)
-		return err
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
 	}
 	return nil
 }
@@ -40,14 +42,14 @@ func (u *psqlUserRepository) Attempt(ctx context.Context, credential *domain.Cre
 	user = new(domain.User)
 	err = u.DB.Model(user).Where("email = ?", credential.Email).Select()
 	if err != nil {
-		logrus.Warnln(err)
-		return nil, err
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credential.Password))
 	if err != nil {
-		logrus.Warnln(err)
-		return nil, err
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 
 	return user, nil
@@ -60,8 +62,82 @@ func (u *psqlUserRepository) Update(ctx context.Context, usr *domain.User) error
 		UpdateNotZero()
 
 	if err != nil {
-		logrus.Warnln(err)
-		return err
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	return nil
+}
+
+func (u *psqlUserRepository) ChangeEmail(ctx context.Context, id uuid.UUID, email string) error {
+	now := time.Now()
+	res, err := u.DB.ModelContext(ctx, (*domain.User)(nil)).
+		Set("email = ?", email).
+		Set("email_verified_at = NULL").
+		Set("email_verification_sent_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (u *psqlUserRepository) EmailTaken(ctx context.Context, email string, exceptID uuid.UUID) (bool, error) {
+	taken, err := u.DB.ModelContext(ctx, (*domain.User)(nil)).
+		AllWithDeleted().
+		Where("email = ?", email).
+		Where("id != ?", exceptID).
+		Exists()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return false, translateError(err)
+	}
+	return taken, nil
+}
+
+func (u *psqlUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := database.Conn(ctx, u.DB).ModelContext(ctx, (*domain.User)(nil)).Where("id = ?", id).Delete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Restore brings a soft deleted user back.
+func (u *psqlUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	res, err := u.DB.ModelContext(ctx, (*domain.User)(nil)).
+		Deleted().
+		Set("deleted_at = NULL").
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ForceDelete permanently removes a user that has already been soft deleted.
+func (u *psqlUserRepository) ForceDelete(ctx context.Context, id uuid.UUID) error {
+	res, err := u.DB.ModelContext(ctx, (*domain.User)(nil)).Where("id = ?", id).ForceDelete()
+	if err != nil {
+		logging.FromContext(ctx).Warnln(err)
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrNotFound
 	}
 	return nil
 }
@@ -70,8 +146,8 @@ func (u *psqlUserRepository) Find(ctx context.Context, id uuid.UUID) (user *doma
 	user = new(domain.User)
 	err = u.DB.Model(user).Where("id = ? ", id).First()
 	if err != nil {
-		logrus.Warnln(err)
-		return nil, err
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 
 	return user, nil
@@ -80,12 +156,22 @@ func (u *psqlUserRepository) Find(ctx context.Context, id uuid.UUID) (user *doma
 func (u *psqlUserRepository) FindBy(ctx context.Context, key, value string) (user *domain.User, err error) {
 	user = new(domain.User)
 	if err := u.DB.Model(user).Where(key+"=?", value).First(); err != nil {
-		return nil, err
-		logrus.Warnln(err)
+		logging.FromContext(ctx).Warnln(err)
+		return nil, translateError(err)
 	}
 	return user, nil
 }
 
-func NewPsqlUserRepository(db *pg.DB) domain.UserRepository {
+func NewPsqlUserRepository(db orm.DB) domain.UserRepository {
 	return &psqlUserRepository{DB: db}
 }
+
+// uniques are the domain errors a taken unique column of the user tables is reported as.
+var uniques = map[string]error{
+	"users_email_unique": domain.ErrEmailTaken,
+}
+
+// translateError is shared by the repositories of this package.
+func translateError(err error) error {
+	return database.TranslateError(err, uniques)
+}
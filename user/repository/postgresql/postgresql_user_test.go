@@ -0,0 +1,20 @@
+package postgresql_test
+
+import (
+	"go-boilerplate/db/dbtest"
+	"go-boilerplate/domain"
+	"go-boilerplate/user/repository/contract"
+	"go-boilerplate/user/repository/postgresql"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	db := dbtest.Open(t)
+	if db == nil {
+		t.Skip("TEST_DATABASE_URL is not set, the contract runs against the in-memory repository only")
+	}
+
+	contract.UserRepository(t, func(t *testing.T) domain.UserRepository {
+		return postgresql.NewPsqlUserRepository(dbtest.Tx(t, db))
+	})
+}
@@ -2,17 +2,28 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 	"go-boilerplate/domain"
 	"go-boilerplate/helper"
+	"go-boilerplate/logging"
 	"golang.org/x/crypto/bcrypt"
+	"net/url"
 	"time"
 )
 
 type userUsecase struct {
-	UserRepo       domain.UserRepository
-	ContextTimeout time.Duration
+	UserRepo          domain.UserRepository
+	RefreshTokenRepo  domain.RefreshTokenRepository
+	PasswordResetRepo domain.PasswordResetRepository
+	RoleRepo          domain.RoleRepository
+	ArticleRepo       domain.ArticleRepository
+	Mailer            domain.Mailer
+	Transactor        domain.Transactor
+	ContextTimeout    time.Duration
 }
 
 func (u *userUsecase) Fetch(ctx context.Context, limit, offset int) (res interface{}, err error) {
@@ -37,42 +48,436 @@ func (u *userUsecase) Register(ctx context.Context, usr *domain.User) error {
 	}
 	usr.ID = uuid.New()
 	usr.Password = string(hashedPassword)
+	usr.EmailVerificationSentAt = pg.NullTime{Time: time.Now()}
 
 	err = u.UserRepo.CreateUser(ctx, usr)
 	if err != nil {
 		return err
 	}
 
+	// The account exists at this point, a failed mail can be retried through the resend endpoint.
+	if err := u.sendVerification(ctx, usr); err != nil {
+		logging.FromContext(ctx).Warnln(err)
+	}
+
 	return nil
 }
 
+func (u *userUsecase) VerifyEmail(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	userID, email, err := helper.ParseVerificationToken(token)
+	if err != nil {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	user, err := u.UserRepo.Find(ctx, userID)
+	if err != nil || user.Email != email {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	if !user.EmailVerifiedAt.IsZero() {
+		return nil
+	}
+
+	user.EmailVerifiedAt = pg.NullTime{Time: time.Now()}
+	user.UpdatedAt = time.Now()
+	return u.UserRepo.Update(ctx, user)
+}
+
+func (u *userUsecase) ResendVerification(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.UserRepo.Find(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.EmailVerifiedAt.IsZero() {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	if time.Since(user.EmailVerificationSentAt.Time) < helper.VerificationCooldown() {
+		return domain.ErrVerificationCooldown
+	}
+
+	if err := u.sendVerification(ctx, user); err != nil {
+		return err
+	}
+
+	user.EmailVerificationSentAt = pg.NullTime{Time: time.Now()}
+	return u.UserRepo.Update(ctx, user)
+}
+
+func (u *userUsecase) sendVerification(ctx context.Context, user *domain.User) error {
+	token, err := helper.GenerateVerificationToken(user)
+	if err != nil {
+		return err
+	}
+
+	link := helper.AppURL("/user/verify/"+token, nil)
+
+	return u.Mailer.Send(ctx, &domain.Mail{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below, it expires in %s.\n\n%s",
+			user.Name, helper.VerificationDuration(), link),
+	})
+}
+
 func (u *userUsecase) Login(ctx context.Context, credential *domain.Credential) (res interface{}, err error) {
 	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
 	defer cancel()
 
 	user, err := u.UserRepo.Attempt(ctx, credential)
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(ctx, user, uuid.New())
+}
+
+func (u *userUsecase) RefreshToken(ctx context.Context, token string) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	stored, err := u.RefreshTokenRepo.FindByHash(ctx, helper.HashToken(token))
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// A token that was already rotated or revoked is being replayed, whoever holds it
+	// may have stolen the session so the whole family goes.
+	used, err := u.RefreshTokenRepo.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		if err := u.RefreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	user, err := u.UserRepo.Find(ctx, stored.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return u.issueTokens(ctx, user, stored.FamilyID)
+}
+
+func (u *userUsecase) Logout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	return u.RefreshTokenRepo.RevokeByAccessToken(ctx, principal.TokenID)
+}
+
+// Profile returns the signed in user as currently stored, not as baked into the access token.
+func (u *userUsecase) Profile(ctx context.Context) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.UserRepo.Find(ctx, principal.UserID)
 	if err != nil {
-		return nil, errors.New("Email atau kata sandi tidak sesuai.\n Silakan tulis email terdaftar atau kata sandi yang sesuai.")
+		return nil, err
 	}
 
-	token, exp, err := helper.GenerateJwt(ctx, user)
+	user.Roles, user.Permissions, err = u.RoleRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *userUsecase) UpdateProfile(ctx context.Context, name, email string) (res interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	user, err := u.UserRepo.Find(ctx, principal.UserID)
 	if err != nil {
 		return nil, err
 	}
 
+	if name != "" && name != user.Name {
+		user.Name = name
+		user.UpdatedAt = time.Now()
+		if err := u.UserRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if email != "" && email != user.Email {
+		taken, err := u.UserRepo.EmailTaken(ctx, email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, domain.ErrEmailTaken
+		}
+
+		if err := u.UserRepo.ChangeEmail(ctx, user.ID, email); err != nil {
+			return nil, err
+		}
+
+		user.Email = email
+		user.EmailVerifiedAt = pg.NullTime{}
+		// The address changed either way, a failed mail can be retried through the resend endpoint.
+		if err := u.sendVerification(ctx, user); err != nil {
+			logging.FromContext(ctx).Warnln(err)
+		}
+	}
+
+	return u.UserRepo.Find(ctx, user.ID)
+}
+
+func (u *userUsecase) ChangePassword(ctx context.Context, current, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.confirmPassword(ctx, principal.UserID, current)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	if err := u.UserRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return u.RefreshTokenRepo.RevokeOtherSessions(ctx, user.ID, principal.TokenID)
+}
+
+// DeleteAccount soft deletes the signed in user, ends all their sessions and
+// leaves their articles in place without an author.
+func (u *userUsecase) DeleteAccount(ctx context.Context, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	principal, err := helper.RequirePrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.confirmPassword(ctx, principal.UserID, password)
+	if err != nil {
+		return err
+	}
+
+	return u.Transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.ArticleRepo.Anonymize(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if err := u.RefreshTokenRepo.RevokeUser(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return u.UserRepo.Delete(ctx, user.ID)
+	})
+}
+
+// confirmPassword loads the user and checks password against their current one.
+func (u *userUsecase) confirmPassword(ctx context.Context, userID uuid.UUID, password string) (*domain.User, error) {
+	user, err := u.UserRepo.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+func (u *userUsecase) ForgotPassword(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	user, err := u.UserRepo.FindBy(ctx, "email", email)
+	if errors.Is(err, domain.ErrNotFound) {
+		// Answer the same way for unknown addresses so the endpoint can not be used to probe for accounts.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	if err := u.PasswordResetRepo.DeleteByEmail(ctx, user.Email); err != nil {
+		return err
+	}
+
+	err = u.PasswordResetRepo.Create(ctx, &domain.PasswordReset{
+		Email:     user.Email,
+		Token:     hash,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := helper.AppURL("/password/reset", url.Values{"token": {token}, "email": {user.Email}})
+
+	return u.Mailer.Send(ctx, &domain.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password, it expires in %s.\n\n%s\n\n"+
+			"Reset token: %s\n\nIf you did not ask for a password reset you can ignore this mail.",
+			user.Name, helper.PasswordResetDuration(), link, token),
+	})
+}
+
+func (u *userUsecase) ResetPassword(ctx context.Context, email, token, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.ContextTimeout)
+	defer cancel()
+
+	reset, err := u.PasswordResetRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return domain.ErrInvalidResetToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(reset.Token), []byte(helper.HashToken(token))) != 1 {
+		return domain.ErrInvalidResetToken
+	}
+
+	if time.Since(reset.CreatedAt) > helper.PasswordResetDuration() {
+		_ = u.PasswordResetRepo.DeleteByEmail(ctx, email)
+		return domain.ErrInvalidResetToken
+	}
+
+	user, err := u.UserRepo.FindBy(ctx, "email", email)
+	if err != nil {
+		return domain.ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	if err := u.UserRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := u.RefreshTokenRepo.RevokeUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return u.PasswordResetRepo.DeleteByEmail(ctx, email)
+}
+
+// issueTokens signs a new access token and stores the refresh token that goes with it in familyID.
+func (u *userUsecase) issueTokens(ctx context.Context, user *domain.User, familyID uuid.UUID) (res interface{}, err error) {
+	user.Roles, user.Permissions, err = u.RoleRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, jti, exp, err := helper.GenerateJwt(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, err := helper.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &domain.RefreshToken{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		FamilyID:      familyID,
+		TokenHash:     hash,
+		AccessTokenID: jti,
+		ExpiresAt:     time.Now().Add(helper.RefreshTokenDuration()),
+		CreatedAt:     time.Now(),
+	}
+
+	if err := u.RefreshTokenRepo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"token_type":   "Bearer",
-		"access_token": token,
-		"expires_in":   exp,
-		"profile":      user,
+		"token_type":         "Bearer",
+		"access_token":       token,
+		"expires_in":         exp,
+		"refresh_token":      refreshToken,
+		"refresh_expires_in": stored.ExpiresAt.Unix(),
+		"profile":            user,
 	}, nil
+}
+
+func (u *userUsecase) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return u.UserRepo.Delete(ctx, id)
+}
+
+func (u *userUsecase) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	return u.UserRepo.Restore(ctx, id)
+}
 
+func (u *userUsecase) PurgeUser(ctx context.Context, id uuid.UUID) error {
+	return u.UserRepo.ForceDelete(ctx, id)
 }
 
-func NewUserUsecase(userRepo domain.UserRepository, duration time.Duration) domain.UserUseCase {
+func NewUserUsecase(userRepo domain.UserRepository, refreshTokenRepo domain.RefreshTokenRepository, passwordResetRepo domain.PasswordResetRepository, roleRepo domain.RoleRepository, articleRepo domain.ArticleRepository, mailer domain.Mailer, transactor domain.Transactor, duration time.Duration) domain.UserUseCase {
 	return &userUsecase{
-		UserRepo:       userRepo,
-		ContextTimeout: duration,
+		UserRepo:          userRepo,
+		RefreshTokenRepo:  refreshTokenRepo,
+		PasswordResetRepo: passwordResetRepo,
+		RoleRepo:          roleRepo,
+		ArticleRepo:       articleRepo,
+		Mailer:            mailer,
+		Transactor:        transactor,
+		ContextTimeout:    duration,
 	}
 }
@@ -0,0 +1,733 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go-boilerplate/article/repository/memory"
+	"go-boilerplate/domain"
+	"go-boilerplate/helper"
+	_userMemoryRepository "go-boilerplate/user/repository/memory"
+	"go-boilerplate/user/usecase"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+const password = "secret-password"
+
+func TestMain(m *testing.M) {
+	viper.Set("JWT_SECRET", "test-secret")
+	viper.Set("JWT_EXPIRED_TOKEN_DURATION", 15)
+	viper.Set("APP_URL", "http://localhost")
+	logrus.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+type mailer struct {
+	mu   sync.Mutex
+	sent []domain.Mail
+}
+
+func (m *mailer) Send(ctx context.Context, mail *domain.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, *mail)
+	return nil
+}
+
+// token pulls the token out of the last mail sent to to, pattern has to capture it.
+func (m *mailer) token(t *testing.T, to string, pattern *regexp.Regexp) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To != to {
+			continue
+		}
+		match := pattern.FindStringSubmatch(m.sent[i].Body)
+		if match == nil {
+			t.Fatalf("no token in mail %q", m.sent[i].Body)
+		}
+		return match[1]
+	}
+	t.Fatalf("no mail sent to %s", to)
+	return ""
+}
+
+func (m *mailer) count(to string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, mail := range m.sent {
+		if mail.To == to {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	verificationToken = regexp.MustCompile(`/user/verify/(\S+)`)
+	resetToken        = regexp.MustCompile(`Reset token: (\S+)`)
+)
+
+// roleRepository grants permissions per user, it only implements what the user usecase uses.
+type roleRepository struct {
+	domain.RoleRepository
+	permissions map[uuid.UUID][]string
+}
+
+func (r roleRepository) FindByUser(ctx context.Context, userID uuid.UUID) (roles, permissions []string, err error) {
+	if granted, ok := r.permissions[userID]; ok {
+		return []string{"admin"}, granted, nil
+	}
+	return []string{}, []string{}, nil
+}
+
+type fixture struct {
+	users    domain.UserRepository
+	tokens   domain.RefreshTokenRepository
+	resets   domain.PasswordResetRepository
+	articles domain.ArticleRepository
+	mailer   *mailer
+	usecase  domain.UserUseCase
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		users:    _userMemoryRepository.NewMemoryUserRepository(),
+		tokens:   _userMemoryRepository.NewMemoryRefreshTokenRepository(),
+		resets:   _userMemoryRepository.NewMemoryPasswordResetRepository(),
+		articles: memory.NewMemoryArticleRepository(),
+		mailer:   &mailer{},
+	}
+	roles := roleRepository{permissions: map[uuid.UUID][]string{}}
+	f.usecase = usecase.NewUserUsecase(f.users, f.tokens, f.resets, roles, f.articles, f.mailer, _userMemoryRepository.NewMemoryTransactor(), time.Second)
+	return f
+}
+
+func (f *fixture) register(t *testing.T, email string) *domain.User {
+	t.Helper()
+	usr := &domain.User{Name: "Jane", Email: email, Password: password, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := f.usecase.Register(context.Background(), usr); err != nil {
+		t.Fatalf("register %s: %v", email, err)
+	}
+	return usr
+}
+
+// login signs in and returns a context carrying the session like the Auth middleware would.
+func (f *fixture) login(t *testing.T, email, pass string) (context.Context, map[string]interface{}) {
+	t.Helper()
+	res, err := f.usecase.Login(context.Background(), &domain.Credential{Email: email, Password: pass})
+	if err != nil {
+		t.Fatalf("login %s: %v", email, err)
+	}
+	tokens := res.(map[string]interface{})
+	principal, err := helper.ParsePrincipal(tokens["access_token"].(string))
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	return helper.WithPrincipal(context.Background(), principal), tokens
+}
+
+func TestRegister(t *testing.T) {
+	f := newFixture()
+	f.register(t, "taken@example.com")
+
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{name: "new address", email: "jane@example.com"},
+		{name: "taken address", email: "taken@example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usr := &domain.User{Name: "Jane", Email: tt.email, Password: password}
+			err := f.usecase.Register(context.Background(), usr)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrEmailTaken) {
+					t.Fatalf("err = %v, want %v", err, domain.ErrEmailTaken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			stored, err := f.users.Find(context.Background(), usr.ID)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			if stored.Password == password {
+				t.Error("password is stored in plain text")
+			}
+			if f.mailer.count(tt.email) != 1 {
+				t.Error("no verification mail sent")
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	f := newFixture()
+	f.register(t, "jane@example.com")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  bool
+	}{
+		{name: "valid credentials", email: "jane@example.com", password: password},
+		{name: "wrong password", email: "jane@example.com", password: "wrong", wantErr: true},
+		{name: "unknown email", email: "john@example.com", password: password, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.Login(context.Background(), &domain.Credential{Email: tt.email, Password: tt.password})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			tokens := res.(map[string]interface{})
+			if _, err := helper.ParsePrincipal(tokens["access_token"].(string)); err != nil {
+				t.Errorf("access token does not verify: %v", err)
+			}
+			if tokens["refresh_token"] == "" {
+				t.Error("no refresh token")
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	f := newFixture()
+	usr := f.register(t, "jane@example.com")
+
+	_, tokens := f.login(t, "jane@example.com", password)
+	rotated := tokens["refresh_token"].(string)
+	if _, err := f.usecase.RefreshToken(context.Background(), rotated); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	_, tokens = f.login(t, "jane@example.com", password)
+	valid := tokens["refresh_token"].(string)
+
+	expired, hash, _ := helper.GenerateOpaqueToken()
+	err := f.tokens.Create(context.Background(), &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    usr.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(-time.Minute),
+		CreatedAt: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid token", token: valid},
+		{name: "unknown token", token: "unknown", wantErr: domain.ErrInvalidRefreshToken},
+		{name: "expired token", token: expired, wantErr: domain.ErrInvalidRefreshToken},
+		{name: "replayed token", token: rotated, wantErr: domain.ErrRefreshTokenReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.RefreshToken(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.(map[string]interface{})["refresh_token"] == tt.token {
+				t.Error("refresh token was not rotated")
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	f := newFixture()
+	f.register(t, "jane@example.com")
+	ctx, _ := f.login(t, "jane@example.com", password)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "signed in", ctx: ctx},
+		{name: "anonymous", ctx: context.Background(), wantErr: domain.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.usecase.Logout(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			principal, _ := helper.PrincipalFromContext(tt.ctx)
+			revoked, _ := f.tokens.IsAccessTokenRevoked(context.Background(), principal.TokenID)
+			if !revoked {
+				t.Error("session was not revoked")
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	f := newFixture()
+	usr := f.register(t, "jane@example.com")
+	ctx, _ := f.login(t, "jane@example.com", password)
+
+	gone := f.register(t, "gone@example.com")
+	goneCtx, _ := f.login(t, "gone@example.com", password)
+	if err := f.users.Delete(context.Background(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    uuid.UUID
+		wantErr error
+	}{
+		{name: "signed in", ctx: ctx, want: usr.ID},
+		{name: "anonymous", ctx: context.Background(), wantErr: domain.ErrUnauthenticated},
+		{name: "deleted account", ctx: goneCtx, wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.Profile(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && res.(*domain.User).ID != tt.want {
+				t.Errorf("profile of %v, want %v", res.(*domain.User).ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		anonymous bool
+		newName   string
+		email     string
+		wantErr   error
+		want      domain.User
+	}{
+		{name: "rename", newName: "Janet", want: domain.User{Name: "Janet", Email: "jane@example.com"}},
+		{name: "change email", email: "janet@example.com", want: domain.User{Name: "Jane", Email: "janet@example.com"}},
+		{name: "email taken", email: "taken@example.com", wantErr: domain.ErrEmailTaken},
+		{name: "anonymous", anonymous: true, newName: "Janet", wantErr: domain.ErrUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			f.register(t, "taken@example.com")
+			usr := f.register(t, "jane@example.com")
+			ctx, _ := f.login(t, "jane@example.com", password)
+			if tt.anonymous {
+				ctx = context.Background()
+			}
+
+			res, err := f.usecase.UpdateProfile(ctx, tt.newName, tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			updated := res.(*domain.User)
+			if updated.ID != usr.ID || updated.Name != tt.want.Name || updated.Email != tt.want.Email {
+				t.Errorf("got %s <%s>, want %s <%s>", updated.Name, updated.Email, tt.want.Name, tt.want.Email)
+			}
+			if tt.email != "" && (!updated.EmailVerifiedAt.IsZero() || f.mailer.count(tt.email) != 1) {
+				t.Error("new address was not sent a verification mail")
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		wantErr error
+	}{
+		{name: "correct current password", current: password},
+		{name: "wrong current password", current: "wrong", wantErr: domain.ErrInvalidPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			f.register(t, "jane@example.com")
+			ctx, _ := f.login(t, "jane@example.com", password)
+			other, _ := f.login(t, "jane@example.com", password)
+
+			err := f.usecase.ChangePassword(ctx, tt.current, "new-password")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			f.login(t, "jane@example.com", "new-password")
+			if !revoked(f, other) {
+				t.Error("other session is still valid")
+			}
+			if revoked(f, ctx) {
+				t.Error("current session was revoked")
+			}
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "correct password", password: password},
+		{name: "wrong password", password: "wrong", wantErr: domain.ErrInvalidPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			usr := f.register(t, "jane@example.com")
+			ctx, _ := f.login(t, "jane@example.com", password)
+
+			article := &domain.Article{ID: uuid.New(), Title: "Hello", Slug: "hello", AuthorID: usr.ID}
+			if err := f.articles.Create(context.Background(), article); err != nil {
+				t.Fatal(err)
+			}
+
+			err := f.usecase.DeleteAccount(ctx, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if _, err := f.users.Find(context.Background(), usr.ID); !errors.Is(err, domain.ErrNotFound) {
+				t.Errorf("user still exists, err = %v", err)
+			}
+			if !revoked(f, ctx) {
+				t.Error("session is still valid")
+			}
+			stored, _ := f.articles.FindWithTrashed(context.Background(), article.ID)
+			if stored.AuthorID != uuid.Nil {
+				t.Error("article still has the deleted author")
+			}
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	f := newFixture()
+	f.register(t, "jane@example.com")
+
+	tests := []struct {
+		name     string
+		email    string
+		wantMail bool
+	}{
+		{name: "registered address", email: "jane@example.com", wantMail: true},
+		{name: "unknown address", email: "john@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.usecase.ForgotPassword(context.Background(), tt.email); err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			_, err := f.resets.FindByEmail(context.Background(), tt.email)
+			if tt.wantMail != (err == nil) {
+				t.Errorf("reset stored = %v, want %v", err == nil, tt.wantMail)
+			}
+			if tt.wantMail {
+				f.mailer.token(t, tt.email, resetToken)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		token   func(t *testing.T, f *fixture) string
+		wantErr error
+	}{
+		{name: "valid token", email: "jane@example.com", token: func(t *testing.T, f *fixture) string {
+			return f.mailer.token(t, "jane@example.com", resetToken)
+		}},
+		{name: "wrong token", email: "jane@example.com", token: func(t *testing.T, f *fixture) string {
+			return "wrong"
+		}, wantErr: domain.ErrInvalidResetToken},
+		{name: "no reset requested", email: "john@example.com", token: func(t *testing.T, f *fixture) string {
+			return f.mailer.token(t, "jane@example.com", resetToken)
+		}, wantErr: domain.ErrInvalidResetToken},
+		{name: "expired token", email: "jane@example.com", token: func(t *testing.T, f *fixture) string {
+			token, hash, _ := helper.GenerateOpaqueToken()
+			_ = f.resets.DeleteByEmail(context.Background(), "jane@example.com")
+			_ = f.resets.Create(context.Background(), &domain.PasswordReset{
+				Email: "jane@example.com", Token: hash, CreatedAt: time.Now().Add(-2 * helper.PasswordResetDuration()),
+			})
+			return token
+		}, wantErr: domain.ErrInvalidResetToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			f.register(t, "jane@example.com")
+			ctx, _ := f.login(t, "jane@example.com", password)
+			if err := f.usecase.ForgotPassword(context.Background(), "jane@example.com"); err != nil {
+				t.Fatal(err)
+			}
+
+			err := f.usecase.ResetPassword(context.Background(), tt.email, tt.token(t, f), "new-password")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			f.login(t, "jane@example.com", "new-password")
+			if !revoked(f, ctx) {
+				t.Error("existing session is still valid")
+			}
+			if _, err := f.resets.FindByEmail(context.Background(), tt.email); !errors.Is(err, domain.ErrNotFound) {
+				t.Error("reset token can be used again")
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(t *testing.T, f *fixture, usr *domain.User) string
+		wantErr error
+	}{
+		{name: "valid token", token: func(t *testing.T, f *fixture, usr *domain.User) string {
+			return f.mailer.token(t, usr.Email, verificationToken)
+		}},
+		{name: "forged token", token: func(t *testing.T, f *fixture, usr *domain.User) string {
+			return "forged"
+		}, wantErr: domain.ErrInvalidVerificationToken},
+		{name: "token for a previous address", token: func(t *testing.T, f *fixture, usr *domain.User) string {
+			token := f.mailer.token(t, usr.Email, verificationToken)
+			if err := f.users.ChangeEmail(context.Background(), usr.ID, "janet@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, wantErr: domain.ErrInvalidVerificationToken},
+		{name: "already verified", token: func(t *testing.T, f *fixture, usr *domain.User) string {
+			token := f.mailer.token(t, usr.Email, verificationToken)
+			if err := f.usecase.VerifyEmail(context.Background(), token); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			usr := f.register(t, "jane@example.com")
+
+			err := f.usecase.VerifyEmail(context.Background(), tt.token(t, f, usr))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			stored, _ := f.users.Find(context.Background(), usr.ID)
+			if stored.EmailVerifiedAt.IsZero() {
+				t.Error("address is not verified")
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(f *fixture, usr *domain.User)
+		wantErr error
+	}{
+		{name: "after the cooldown", prepare: func(f *fixture, usr *domain.User) {
+			usr.EmailVerificationSentAt = pg.NullTime{Time: time.Now().Add(-time.Hour)}
+			_ = f.users.Update(context.Background(), usr)
+		}},
+		{name: "within the cooldown", prepare: func(f *fixture, usr *domain.User) {}, wantErr: domain.ErrVerificationCooldown},
+		{name: "already verified", prepare: func(f *fixture, usr *domain.User) {
+			usr.EmailVerifiedAt = pg.NullTime{Time: time.Now()}
+			_ = f.users.Update(context.Background(), usr)
+		}, wantErr: domain.ErrEmailAlreadyVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			usr := f.register(t, "jane@example.com")
+			ctx, _ := f.login(t, "jane@example.com", password)
+			tt.prepare(f, usr)
+
+			err := f.usecase.ResendVerification(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && f.mailer.count(usr.Email) != 2 {
+				t.Error("verification mail was not sent again")
+			}
+		})
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		f := newFixture()
+		if err := f.usecase.ResendVerification(context.Background()); !errors.Is(err, domain.ErrUnauthenticated) {
+			t.Fatalf("err = %v, want %v", err, domain.ErrUnauthenticated)
+		}
+	})
+}
+
+func TestFetch(t *testing.T) {
+	f := newFixture()
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		f.register(t, email)
+		time.Sleep(time.Millisecond)
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []string
+	}{
+		{name: "first page", limit: 2, want: []string{"a@example.com", "b@example.com"}},
+		{name: "second page", limit: 2, offset: 2, want: []string{"c@example.com"}},
+		{name: "past the end", limit: 2, offset: 4, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := f.usecase.Fetch(context.Background(), tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			users := res.(map[string]interface{})["users"].([]domain.User)
+			if len(users) != len(tt.want) {
+				t.Fatalf("got %d users, want %d", len(users), len(tt.want))
+			}
+			for i, usr := range users {
+				if usr.Email != tt.want[i] {
+					t.Errorf("users[%d] = %s, want %s", i, usr.Email, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrashUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		trashed bool
+		action  func(u domain.UserUseCase) func(ctx context.Context, id uuid.UUID) error
+		wantErr error
+		// visible is whether Find still sees the user afterwards.
+		visible bool
+	}{
+		{name: "delete", action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.DeleteUser }},
+		{name: "delete trashed", trashed: true, wantErr: domain.ErrNotFound,
+			action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.DeleteUser }},
+		{name: "restore", trashed: true, visible: true,
+			action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.RestoreUser }},
+		{name: "restore live", wantErr: domain.ErrNotFound, visible: true,
+			action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.RestoreUser }},
+		{name: "purge", trashed: true,
+			action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.PurgeUser }},
+		{name: "purge live", wantErr: domain.ErrNotFound, visible: true,
+			action: func(u domain.UserUseCase) func(context.Context, uuid.UUID) error { return u.PurgeUser }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			usr := f.register(t, "jane@example.com")
+			if tt.trashed {
+				if err := f.users.Delete(context.Background(), usr.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := tt.action(f.usecase)(context.Background(), usr.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = f.users.Find(context.Background(), usr.ID)
+			if tt.visible != (err == nil) {
+				t.Errorf("user visible = %v, want %v", err == nil, tt.visible)
+			}
+		})
+	}
+
+	t.Run("purge frees the address", func(t *testing.T) {
+		f := newFixture()
+		usr := f.register(t, "jane@example.com")
+		_ = f.usecase.DeleteUser(context.Background(), usr.ID)
+		if err := f.usecase.PurgeUser(context.Background(), usr.ID); err != nil {
+			t.Fatal(err)
+		}
+		f.register(t, "jane@example.com")
+	})
+}
+
+// revoked tells whether the session in ctx has been ended.
+func revoked(f *fixture, ctx context.Context) bool {
+	principal, _ := helper.PrincipalFromContext(ctx)
+	revoked, _ := f.tokens.IsAccessTokenRevoked(context.Background(), principal.TokenID)
+	return revoked
+}